@@ -0,0 +1,236 @@
+// Package alerts resolves the links of Google Alerts feeds and emails to
+// publisher URLs.
+//
+// Alerts links are google.com/url redirects, sometimes wrapping a Google News
+// article URL:
+//
+//	https://www.google.com/url?rct=j&sa=t&url=https://news.google.com/rss/articles/CBMi...&ct=ga
+//
+// A Resolver removes the redirects and decodes any Google News ID found inside:
+//
+//	resolver := alerts.NewResolver(decoder)
+//	entries, err := resolver.ResolveFeed(ctx, feed)
+package alerts
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	gnews "github.com/alainmucyo/google-news-url-decoder"
+)
+
+// maxHops bounds how many Google News decodes a single link may go through
+const maxHops = 3
+
+// Resolver resolves Alerts links to publisher URLs
+type Resolver struct {
+	decoder *gnews.GoogleDecoder
+}
+
+// NewResolver returns a Resolver decoding Google News URLs with the given decoder
+func NewResolver(decoder *gnews.GoogleDecoder) *Resolver {
+	return &Resolver{decoder: decoder}
+}
+
+// Resolve removes the redirects around link and decodes the Google News URL
+// found inside, if any. Only opaque Google News IDs need network access.
+// Links still pointing to Google after maxHops decodes are an error.
+func (r *Resolver) Resolve(ctx context.Context, link string) (string, error) {
+	current := link
+	for hop := 0; hop < maxHops; hop++ {
+		current = gnews.UnwrapRedirects(current)
+		if _, err := gnews.ClassifyURL(current); err != nil {
+			// Not a Google News URL, so this is the publisher URL
+			return current, nil
+		}
+
+		// Inline IDs carry the URL, only opaque ones need Google
+		if articleID, err := gnews.DecodeArticleID(current); err == nil && !articleID.Opaque() {
+			current = articleID.URL
+			continue
+		}
+
+		result := r.decoder.DecodeWithContext(ctx, current, nil)
+		if !result.Status {
+			if result.Err != nil {
+				return "", result.Err
+			}
+			return "", errors.New(result.Message)
+		}
+		current = result.DecodedURL
+	}
+
+	current = gnews.UnwrapRedirects(current)
+	if isGoogleURL(current) {
+		return "", fmt.Errorf("link still points to Google after %d hops: %s", maxHops, current)
+	}
+	return current, nil
+}
+
+// isGoogleURL reports whether link is on google.com or one of its subdomains
+func isGoogleURL(link string) bool {
+	if _, err := gnews.ClassifyURL(link); err == nil {
+		return true
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	return host == "google.com" || strings.HasSuffix(host, ".google.com")
+}
+
+// Entry is one item of an Alerts feed
+type Entry struct {
+	ID        string `json:"id,omitempty"`
+	Title     string `json:"title"`
+	Link      string `json:"link"`
+	URL       string `json:"url,omitempty"`
+	Published string `json:"published,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// feed holds both the Atom and the RSS 2.0 layout of an Alerts feed
+type feed struct {
+	Entries []struct {
+		ID    string `xml:"id"`
+		Title string `xml:"title"`
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+		Published string `xml:"published"`
+		Updated   string `xml:"updated"`
+	} `xml:"entry"`
+	Items []struct {
+		GUID    string `xml:"guid"`
+		Title   string `xml:"title"`
+		Link    string `xml:"link"`
+		PubDate string `xml:"pubDate"`
+	} `xml:"channel>item"`
+}
+
+// ParseFeed reads the entries of an Alerts feed in Atom or RSS 2.0 format.
+// Titles are returned as plain text.
+func ParseFeed(r io.Reader) ([]Entry, error) {
+	var f feed
+	if err := xml.NewDecoder(r).Decode(&f); err != nil {
+		return nil, fmt.Errorf("failed to parse feed: %v", err)
+	}
+
+	var entries []Entry
+	for _, e := range f.Entries {
+		entry := Entry{ID: e.ID, Title: plainText(e.Title), Published: e.Published}
+		if entry.Published == "" {
+			entry.Published = e.Updated
+		}
+		for _, link := range e.Links {
+			if link.Rel == "" || link.Rel == "alternate" {
+				entry.Link = strings.TrimSpace(link.Href)
+				break
+			}
+		}
+		entries = append(entries, entry)
+	}
+	for _, item := range f.Items {
+		entries = append(entries, Entry{
+			ID:        item.GUID,
+			Title:     plainText(item.Title),
+			Link:      strings.TrimSpace(item.Link),
+			Published: item.PubDate,
+		})
+	}
+	return entries, nil
+}
+
+// ResolveFeed parses an Alerts feed and resolves the link of every entry.
+// Links that fail to resolve are reported in Entry.Error.
+func (r *Resolver) ResolveFeed(ctx context.Context, feed io.Reader) ([]Entry, error) {
+	entries, err := ParseFeed(feed)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range entries {
+		if err := ctx.Err(); err != nil {
+			return entries, err
+		}
+		if entries[i].Link == "" {
+			entries[i].Error = "entry has no link"
+			continue
+		}
+		resolved, err := r.Resolve(ctx, entries[i].Link)
+		if err != nil {
+			entries[i].Error = err.Error()
+			continue
+		}
+		entries[i].URL = resolved
+	}
+	return entries, nil
+}
+
+// ExtractLinks returns the Alerts links of an HTML email body in document
+// order, without duplicates: google.com/url redirects and Google News URLs.
+func ExtractLinks(body io.Reader) ([]string, error) {
+	var links []string
+	seen := make(map[string]bool)
+
+	z := html.NewTokenizer(body)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if errors.Is(z.Err(), io.EOF) {
+				return links, nil
+			}
+			return links, z.Err()
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			if string(name) != "a" {
+				continue
+			}
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				if string(key) != "href" {
+					continue
+				}
+				link := strings.TrimSpace(string(val))
+				if seen[link] || !isAlertsLink(link) {
+					continue
+				}
+				seen[link] = true
+				links = append(links, link)
+			}
+		}
+	}
+}
+
+// isAlertsLink reports whether link is a Google redirect or a Google News URL
+func isAlertsLink(link string) bool {
+	if gnews.UnwrapRedirects(link) != link {
+		return true
+	}
+	_, err := gnews.ClassifyURL(link)
+	return err == nil
+}
+
+// plainText strips the HTML markup Alerts puts in titles
+func plainText(s string) string {
+	var b strings.Builder
+	z := html.NewTokenizer(strings.NewReader(s))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return strings.Join(strings.Fields(b.String()), " ")
+		case html.TextToken:
+			b.Write(z.Text())
+		}
+	}
+}
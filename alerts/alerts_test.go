@@ -0,0 +1,137 @@
+package alerts_test
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+
+	gnews "github.com/alainmucyo/google-news-url-decoder"
+	"github.com/alainmucyo/google-news-url-decoder/alerts"
+	"github.com/alainmucyo/google-news-url-decoder/gnewstest"
+)
+
+// alertsLink wraps target in a Google Alerts redirect
+func alertsLink(target string) string {
+	return "https://www.google.com/url?rct=j&sa=t&url=" + url.QueryEscape(target) + "&ct=ga&cd=CAEYACoTNjU&usg=AOvVaw1"
+}
+
+func newResolver(t *testing.T) (*alerts.Resolver, *gnewstest.Server) {
+	t.Helper()
+	srv := gnewstest.NewServer()
+	t.Cleanup(srv.Close)
+
+	decoder, err := gnews.NewGoogleDecoder(srv.Option())
+	if err != nil {
+		t.Fatalf("Failed to create GoogleDecoder: %v", err)
+	}
+	return alerts.NewResolver(decoder), srv
+}
+
+func TestResolver_Resolve(t *testing.T) {
+	resolver, srv := newResolver(t)
+
+	opaque, _ := gnews.EncodeArticleID("AU_yqLalerts", gnews.EncodeOptions{})
+	srv.AddArticle(opaque, "https://example.com/opaque")
+	inline, _ := gnews.EncodeArticleURL("https://example.com/inline", gnews.EncodeOptions{})
+
+	tests := []struct {
+		name string
+		link string
+		want string
+	}{
+		{name: "publisher", link: alertsLink("https://example.com/direct?a=1&b=2"), want: "https://example.com/direct?a=1&b=2"},
+		{name: "inline Google News", link: alertsLink(inline), want: "https://example.com/inline"},
+		{name: "opaque Google News", link: alertsLink("https://news.google.com/rss/articles/" + opaque), want: "https://example.com/opaque"},
+		{name: "nested redirects", link: alertsLink(alertsLink("https://example.com/nested")), want: "https://example.com/nested"},
+		{name: "plain URL", link: "https://example.com/plain", want: "https://example.com/plain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolver.Resolve(context.Background(), tt.link)
+			if err != nil || got != tt.want {
+				t.Errorf("Resolve() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+
+	if _, err := resolver.Resolve(context.Background(), alertsLink("https://news.google.com/rss/articles/CBMiunknown")); err == nil {
+		t.Error("Expected error for an undecodable Google News URL")
+	}
+
+	// Google News URLs nested deeper than the hop limit are not a publisher URL
+	nested := "https://example.com/deep"
+	for i := 0; i < 4; i++ {
+		nested, _ = gnews.EncodeArticleURL(nested, gnews.EncodeOptions{})
+	}
+	if got, err := resolver.Resolve(context.Background(), alertsLink(nested)); err == nil || !strings.Contains(err.Error(), "news.google.com") {
+		t.Errorf("Resolve(nested) = %q, %v, want an error naming the Google News URL", got, err)
+	}
+}
+
+func TestResolver_ResolveFeed(t *testing.T) {
+	resolver, _ := newResolver(t)
+	inline, _ := gnews.EncodeArticleURL("https://example.com/inline", gnews.EncodeOptions{})
+
+	feed := fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:idx="urn:atom-extension:indexing">
+  <id>tag:google.com,2005:reader/user/0/state/com.google/alert/1</id>
+  <title>Google Alert - golang</title>
+  <entry>
+    <id>tag:google.com,2013:googlealerts/feed:1</id>
+    <title type="html">What&#39;s new in &lt;b&gt;Go&lt;/b&gt; 1.24</title>
+    <link href="%s"/>
+    <published>2025-02-11T10:00:00Z</published>
+    <updated>2025-02-11T10:00:00Z</updated>
+    <content type="html">...</content>
+  </entry>
+  <entry>
+    <id>tag:google.com,2013:googlealerts/feed:2</id>
+    <title type="html">&lt;b&gt;Go&lt;/b&gt; on Google News</title>
+    <link href="%s"/>
+    <updated>2025-02-12T10:00:00Z</updated>
+  </entry>
+</feed>`, xmlEscape(alertsLink("https://example.com/go124")), xmlEscape(alertsLink(inline)))
+
+	entries, err := resolver.ResolveFeed(context.Background(), strings.NewReader(feed))
+	if err != nil {
+		t.Fatalf("ResolveFeed() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	if entries[0].Title != "What's new in Go 1.24" || entries[0].URL != "https://example.com/go124" {
+		t.Errorf("entries[0] = %+v", entries[0])
+	}
+	if entries[1].URL != "https://example.com/inline" || entries[1].Published != "2025-02-12T10:00:00Z" {
+		t.Errorf("entries[1] = %+v", entries[1])
+	}
+
+	if _, err := alerts.ParseFeed(strings.NewReader("<feed><entry>")); err == nil {
+		t.Error("Expected error for a truncated feed")
+	}
+}
+
+func TestExtractLinks(t *testing.T) {
+	inline, _ := gnews.EncodeArticleURL("https://example.com/inline", gnews.EncodeOptions{})
+	email := fmt.Sprintf(`<html><body>
+<a href="%[1]s">Story</a> <a href="%[1]s">Story again</a>
+<a href="%[2]s">Google News</a>
+<a href="https://www.google.com/alerts/remove?source=alertsmail">Unsubscribe</a>
+<a href="https://example.com/unrelated">Ad</a>
+</body></html>`, xmlEscape(alertsLink("https://example.com/story")), inline)
+
+	links, err := alerts.ExtractLinks(strings.NewReader(email))
+	if err != nil {
+		t.Fatalf("ExtractLinks() error = %v", err)
+	}
+	if len(links) != 2 || links[0] != alertsLink("https://example.com/story") || links[1] != inline {
+		t.Errorf("ExtractLinks() = %q", links)
+	}
+}
+
+func xmlEscape(s string) string {
+	return strings.ReplaceAll(s, "&", "&amp;")
+}
@@ -0,0 +1,256 @@
+package gnewsdecoder
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Protobuf layout of inline article IDs: a kind varint (field 1), the article
+// URL (field 4) and an optional AMP URL (field 26).
+const (
+	articleIDKindField = 1
+	articleIDURLField  = 4
+	articleIDAMPField  = 26
+
+	// articleIDKind is the kind value found in inline article IDs
+	articleIDKind = 0x13
+)
+
+// Protobuf wire types used by article IDs
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// ArticlePath selects the Google News URL shape produced by EncodeArticleURL
+type ArticlePath int
+
+const (
+	// ArticlePathRSS produces https://news.google.com/rss/articles/<id>
+	ArticlePathRSS ArticlePath = iota
+	// ArticlePathRead produces https://news.google.com/read/<id>
+	ArticlePathRead
+)
+
+// EncodeOptions configures EncodeArticleID and EncodeArticleURL
+type EncodeOptions struct {
+	// AMPURL is stored in the optional AMP URL field of the ID
+	AMPURL string
+	// Path selects the URL shape for EncodeArticleURL
+	Path ArticlePath
+	// Locale adds hl, gl and ceid parameters to the URL for EncodeArticleURL
+	Locale *Locale
+}
+
+// EncodeArticleID produces a Google News style article ID (CBMi...) embedding
+// the given URL. It is the inverse of the offline decoders and is meant for
+// building fixtures and round-trip tests.
+func EncodeArticleID(articleURL string, opts EncodeOptions) (string, error) {
+	if articleURL == "" {
+		return "", errors.New("article URL is empty")
+	}
+
+	var b []byte
+	b = appendTag(b, articleIDKindField, wireVarint)
+	b = appendVarint(b, articleIDKind)
+	b = appendTag(b, articleIDURLField, wireBytes)
+	b = appendVarint(b, uint64(len(articleURL)))
+	b = append(b, articleURL...)
+	if opts.AMPURL != "" {
+		b = appendTag(b, articleIDAMPField, wireBytes)
+		b = appendVarint(b, uint64(len(opts.AMPURL)))
+		b = append(b, opts.AMPURL...)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// EncodeArticleURL wraps an encoded article ID in a news.google.com URL
+func EncodeArticleURL(articleURL string, opts EncodeOptions) (string, error) {
+	id, err := EncodeArticleID(articleURL, opts)
+	if err != nil {
+		return "", err
+	}
+
+	var u string
+	switch opts.Path {
+	case ArticlePathRead:
+		u = "https://news.google.com/read/" + id
+	default:
+		u = "https://news.google.com/rss/articles/" + id
+	}
+
+	if opts.Locale != nil {
+		locale := opts.Locale.complete()
+		if err := locale.Validate(); err != nil {
+			return "", err
+		}
+		u += "?" + locale.query()
+	}
+	return u, nil
+}
+
+// appendTag appends a protobuf field tag
+func appendTag(b []byte, field int, wireType int) []byte {
+	return appendVarint(b, uint64(field)<<3|uint64(wireType))
+}
+
+// appendVarint appends a protobuf base 128 varint
+func appendVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+// protoField is a single field of a decoded protobuf message
+type protoField struct {
+	Number   int
+	WireType int
+	Varint   uint64 // value of varint, fixed32 and fixed64 fields
+	Bytes    []byte // value of length-delimited fields
+}
+
+// parseProtoFields decodes a protobuf message into its top-level fields
+func parseProtoFields(b []byte) ([]protoField, error) {
+	var fields []protoField
+	for len(b) > 0 {
+		tag, n := consumeVarint(b)
+		if n <= 0 {
+			return fields, errors.New("truncated field tag")
+		}
+		b = b[n:]
+
+		field := protoField{Number: int(tag >> 3), WireType: int(tag & 7)}
+		if tag>>3 == 0 || tag>>3 > 1<<29-1 {
+			return fields, fmt.Errorf("invalid field number %d", tag>>3)
+		}
+
+		switch field.WireType {
+		case wireVarint:
+			v, n := consumeVarint(b)
+			if n <= 0 {
+				return fields, fmt.Errorf("field %d: truncated varint", field.Number)
+			}
+			field.Varint = v
+			b = b[n:]
+		case wireFixed64:
+			if len(b) < 8 {
+				return fields, fmt.Errorf("field %d: truncated fixed64", field.Number)
+			}
+			field.Varint = binary.LittleEndian.Uint64(b)
+			b = b[8:]
+		case wireBytes:
+			length, n := consumeVarint(b)
+			if n <= 0 {
+				return fields, fmt.Errorf("field %d: truncated length", field.Number)
+			}
+			b = b[n:]
+			if length > uint64(len(b)) {
+				return fields, fmt.Errorf("field %d: length %d exceeds remaining %d bytes", field.Number, length, len(b))
+			}
+			field.Bytes = b[:length]
+			b = b[length:]
+		case wireFixed32:
+			if len(b) < 4 {
+				return fields, fmt.Errorf("field %d: truncated fixed32", field.Number)
+			}
+			field.Varint = uint64(binary.LittleEndian.Uint32(b))
+			b = b[4:]
+		default:
+			return fields, fmt.Errorf("field %d: unsupported wire type %d", field.Number, field.WireType)
+		}
+
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// consumeVarint decodes a varint, returning its value and length, or n <= 0 if malformed
+func consumeVarint(b []byte) (v uint64, n int) {
+	for i := 0; i < len(b) && i < 10; i++ {
+		v |= uint64(b[i]&0x7F) << (7 * i)
+		if b[i] < 0x80 {
+			if i == 9 && b[i] > 1 {
+				return 0, -1
+			}
+			return v, i + 1
+		}
+	}
+	return 0, -1
+}
+
+// decodeArticleIDBytes decodes the base64url payload of an article ID, with or without padding
+func decodeArticleIDBytes(id string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(id, "="))
+}
+
+// ArticleID holds the content of an inline article ID
+type ArticleID struct {
+	// URL is the article URL, or an AU_yqL token for opaque IDs
+	URL string `json:"url"`
+	// AMPURL is the AMP version of the article, when the ID carries one
+	AMPURL string `json:"amp_url,omitempty"`
+	// Strings holds any other printable length-delimited fields
+	Strings []ArticleIDField `json:"strings,omitempty"`
+}
+
+// Opaque reports whether the ID carries an AU_yqL token that only Google can resolve
+func (a ArticleID) Opaque() bool {
+	return strings.HasPrefix(a.URL, "AU_yqL")
+}
+
+// DecodeArticleID reads the URLs embedded in a Google News article URL or bare
+// article ID without any network access. For opaque IDs URL and AMPURL hold
+// AU_yqL tokens, see ArticleID.Opaque.
+func DecodeArticleID(urlOrID string) (ArticleID, error) {
+	id, err := articleIDFromInput(urlOrID)
+	if err != nil {
+		return ArticleID{}, err
+	}
+	return parseArticleID(id)
+}
+
+// parseArticleID parses the protobuf payload of an article ID
+func parseArticleID(id string) (ArticleID, error) {
+	raw, err := decodeArticleIDBytes(id)
+	if err != nil {
+		return ArticleID{}, fmt.Errorf("failed to decode base64: %v", err)
+	}
+
+	// A damaged trailer after the URL field does not prevent decoding
+	fields, err := parseProtoFields(raw)
+
+	var articleID ArticleID
+	found := false
+	for _, field := range fields {
+		if field.WireType != wireBytes {
+			continue
+		}
+		switch {
+		case field.Number == articleIDURLField && !found:
+			articleID.URL, found = string(field.Bytes), true
+		case field.Number == articleIDAMPField && articleID.AMPURL == "":
+			articleID.AMPURL = string(field.Bytes)
+		case isPrintable(field.Bytes):
+			articleID.Strings = append(articleID.Strings, describeField(field))
+		}
+	}
+
+	switch {
+	case found && articleID.URL == "":
+		return ArticleID{}, errors.New("failed to parse article ID: empty URL field")
+	case found:
+		return articleID, nil
+	case err != nil:
+		return ArticleID{}, fmt.Errorf("failed to parse article ID: %v", err)
+	default:
+		return ArticleID{}, errors.New("failed to parse article ID: no URL field")
+	}
+}
@@ -0,0 +1,98 @@
+package gnewsdecoder_test
+
+import (
+	"strings"
+	"testing"
+
+	gnews "github.com/alainmucyo/google-news-url-decoder"
+)
+
+func TestEncodeArticleID_MatchesGoogle(t *testing.T) {
+	// ID taken from a real Google News RSS feed
+	want := "CBMiLmh0dHBzOi8vd3d3LmJiYy5jb20vbmV3cy9hcnRpY2xlcy9jampqbnhkdjE4OG_SATJodHRwczovL3d3dy5iYmMuY29tL25ld3MvYXJ0aWNsZXMvY2pqam54ZHYxODhvLmFtcA"
+
+	got, err := gnews.EncodeArticleID("https://www.bbc.com/news/articles/cjjjnxdv188o", gnews.EncodeOptions{
+		AMPURL: "https://www.bbc.com/news/articles/cjjjnxdv188o.amp",
+	})
+	if err != nil {
+		t.Fatalf("EncodeArticleID() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("EncodeArticleID() = %s, want %s", got, want)
+	}
+
+	if decoded := gnews.DecoderV1("https://news.google.com/rss/articles/" + got); decoded != "https://www.bbc.com/news/articles/cjjjnxdv188o" {
+		t.Errorf("DecoderV1() = %q", decoded)
+	}
+}
+
+func TestEncodeArticleURL(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       gnews.EncodeOptions
+		wantPrefix string
+		wantQuery  string
+	}{
+		{
+			name:       "RSS",
+			wantPrefix: "https://news.google.com/rss/articles/CBMi",
+		},
+		{
+			name:       "Read",
+			opts:       gnews.EncodeOptions{Path: gnews.ArticlePathRead},
+			wantPrefix: "https://news.google.com/read/CBMi",
+		},
+		{
+			name:       "Locale",
+			opts:       gnews.EncodeOptions{Locale: &gnews.Locale{Edition: "FR:fr"}},
+			wantPrefix: "https://news.google.com/rss/articles/CBMi",
+			wantQuery:  "?ceid=FR%3Afr&gl=FR&hl=fr",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := gnews.EncodeArticleURL("https://example.com/story", tt.opts)
+			if err != nil {
+				t.Fatalf("EncodeArticleURL() error = %v", err)
+			}
+			if !strings.HasPrefix(got, tt.wantPrefix) || !strings.HasSuffix(got, tt.wantQuery) {
+				t.Errorf("EncodeArticleURL() = %s, want prefix %s and query %q", got, tt.wantPrefix, tt.wantQuery)
+			}
+		})
+	}
+
+	if _, err := gnews.EncodeArticleID("", gnews.EncodeOptions{}); err == nil {
+		t.Error("Expected error for empty URL")
+	}
+}
+
+func TestDecodeArticleID(t *testing.T) {
+	sourceURL := "https://news.google.com/rss/articles/CBMiLmh0dHBzOi8vd3d3LmJiYy5jb20vbmV3cy9hcnRpY2xlcy9jampqbnhkdjE4OG_SATJodHRwczovL3d3dy5iYmMuY29tL25ld3MvYXJ0aWNsZXMvY2pqam54ZHYxODhvLmFtcA?oc=5"
+
+	articleID, err := gnews.DecodeArticleID(sourceURL)
+	if err != nil {
+		t.Fatalf("DecodeArticleID() error = %v", err)
+	}
+	if articleID.URL != "https://www.bbc.com/news/articles/cjjjnxdv188o" || articleID.AMPURL != "https://www.bbc.com/news/articles/cjjjnxdv188o.amp" {
+		t.Errorf("DecodeArticleID() = %+v", articleID)
+	}
+	if articleID.Opaque() {
+		t.Error("Inline ID reported as opaque")
+	}
+
+	// The AMP URL must not leak into the decoded URL
+	result := gnews.DecoderV3(sourceURL)
+	if result.DecodedURL != articleID.URL || result.AMPURL != articleID.AMPURL || result.Method != gnews.MethodOffline {
+		t.Errorf("DecoderV3() = %+v", result)
+	}
+
+	opaque, err := gnews.DecodeArticleID(opaqueID("AU_yqLabc", "AU_yqLamp"))
+	if err != nil || !opaque.Opaque() {
+		t.Errorf("DecodeArticleID(opaque) = %+v, %v", opaque, err)
+	}
+
+	if _, err := gnews.DecodeArticleID("CBMiAA"); err == nil {
+		t.Error("Expected error for empty URL field")
+	}
+}
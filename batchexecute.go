@@ -0,0 +1,227 @@
+package gnewsdecoder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// garturlRPCID is the batchexecute RPC that resolves article IDs to URLs
+const garturlRPCID = "Fbv4je"
+
+// batchEnvelope is one RPC call of a batchexecute f.req payload.
+// It serializes as [rpcid, "<args as JSON>", null, tag], or [rpcid, "<args>"] without a tag.
+type batchEnvelope struct {
+	RPCID string
+	Args  any
+	Tag   string
+}
+
+func (e batchEnvelope) MarshalJSON() ([]byte, error) {
+	args, err := json.Marshal(e.Args)
+	if err != nil {
+		return nil, err
+	}
+	if e.Tag == "" {
+		return json.Marshal([]any{e.RPCID, string(args)})
+	}
+	return json.Marshal([]any{e.RPCID, string(args), nil, e.Tag})
+}
+
+// batchRequest is the f.req payload of a batchexecute call
+type batchRequest []batchEnvelope
+
+// encode serializes the request as the f.req form value
+func (r batchRequest) encode() (string, error) {
+	payload, err := json.Marshal([][]batchEnvelope{r})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal payload: %v", err)
+	}
+	return string(payload), nil
+}
+
+// garturlRequest holds the inner arguments of a garturlreq call.
+// With a signature and timestamp it uses the signed form fetched from the article page,
+// otherwise the legacy form used for AU_yqL IDs.
+type garturlRequest struct {
+	ID        string
+	Locale    Locale
+	Timestamp int64
+	Signature string
+}
+
+func (r garturlRequest) MarshalJSON() ([]byte, error) {
+	if r.Signature != "" {
+		return json.Marshal([]any{
+			"garturlreq",
+			[]any{
+				[]any{"X", "X", []any{"X", "X"}, nil, nil, 1, 1, r.Locale.Edition, nil, 1, nil, nil, nil, nil, nil, 0, 1},
+				"X", "X", 1, []any{1, 1, 1}, 1, 1, nil, 0, 0, nil, 0,
+			},
+			r.ID,
+			r.Timestamp,
+			r.Signature,
+		})
+	}
+
+	return json.Marshal([]any{
+		"garturlreq",
+		[]any{
+			[]any{
+				r.Locale.Language, r.Locale.Country, []any{"FINANCE_TOP_INDICES", "WEB_TEST_1_0_0"},
+				nil, nil, 1, 1, r.Locale.Edition, nil, 180, nil, nil, nil, nil, nil, 0, nil, nil,
+				[]any{1608992183, 723341000},
+			},
+			r.Locale.Language, r.Locale.Country, 1, []any{2, 3, 4, 8}, 1, 0, "655000234", 0, 0, nil, 0,
+		},
+		r.ID,
+	})
+}
+
+// newGarturlEnvelope validates the article ID and wraps a garturlreq call in an envelope
+func newGarturlEnvelope(req garturlRequest, tag string) (batchEnvelope, error) {
+	if err := validateArticleID(req.ID); err != nil {
+		return batchEnvelope{}, err
+	}
+	return batchEnvelope{RPCID: garturlRPCID, Args: req, Tag: tag}, nil
+}
+
+// parseTimestamp parses the data-n-a-ts attribute of an article page
+func parseTimestamp(timestamp string) (int64, error) {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timestamp %q", timestamp)
+	}
+	return ts, nil
+}
+
+// validateArticleID checks that an article ID only uses the base64url alphabet
+func validateArticleID(id string) error {
+	if id == "" {
+		return errors.New("invalid article ID: empty")
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		if !(c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return fmt.Errorf("invalid article ID: character %q at position %d is not base64url", c, i)
+		}
+	}
+	return nil
+}
+
+// batchEntry is a wrb.fr entry of a batchexecute response:
+// ["wrb.fr", rpcid, "<result as JSON>" or null, null, null, [error code], tag]
+type batchEntry struct {
+	RPCID string
+	// Payload is the JSON-in-JSON result, empty when the call failed
+	Payload string
+	Status  []any
+	Tag     string
+}
+
+// parseBatchEntries returns the wrb.fr entries of a batchexecute response,
+// either a single JSON array or length-prefixed chunks, after the )]}' prefix
+func parseBatchEntries(body []byte) ([]batchEntry, error) {
+	_, rest, ok := strings.Cut(string(body), "\n")
+	if !ok || !strings.HasPrefix(string(body), ")]}'") {
+		return nil, errors.New("invalid response format")
+	}
+
+	var entries []batchEntry
+	dec := json.NewDecoder(strings.NewReader(rest))
+	for {
+		var chunk any
+		if err := dec.Decode(&chunk); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return entries, fmt.Errorf("failed to parse response JSON: %v", err)
+		}
+		// Chunk lengths are numbers; entries are arrays of arrays
+		rows, _ := chunk.([]any)
+		for _, row := range rows {
+			fields, _ := row.([]any)
+			if len(fields) < 2 || fields[0] != "wrb.fr" {
+				continue
+			}
+			var entry batchEntry
+			entry.RPCID, _ = fields[1].(string)
+			if len(fields) > 2 {
+				entry.Payload, _ = fields[2].(string)
+			}
+			if len(fields) > 5 {
+				entry.Status, _ = fields[5].([]any)
+			}
+			if len(fields) > 6 {
+				entry.Tag, _ = fields[6].(string)
+			}
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// garturlURL returns the URL of a garturlres entry
+func (e batchEntry) garturlURL() (string, error) {
+	if e.RPCID != garturlRPCID {
+		return "", fmt.Errorf("unexpected RPC %q in response", e.RPCID)
+	}
+	if e.Payload == "" {
+		if len(e.Status) > 0 {
+			return "", fmt.Errorf("article not found (error %v)", e.Status[0])
+		}
+		return "", errors.New("article not found")
+	}
+
+	var inner []any
+	if err := json.Unmarshal([]byte(e.Payload), &inner); err != nil {
+		return "", fmt.Errorf("failed to parse inner JSON: %v", err)
+	}
+	if len(inner) < 2 || inner[0] != "garturlres" {
+		return "", errors.New("decoded URL not found in response")
+	}
+	decodedURL, ok := inner[1].(string)
+	if !ok {
+		return "", errors.New("decoded URL is not a string")
+	}
+	if u, err := url.Parse(decodedURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid decoded URL %q", decodedURL)
+	}
+	return decodedURL, nil
+}
+
+// garturlURLs maps the tags of the garturlres entries of a response to their
+// URL, or the error of the call
+func garturlURLs(body []byte) (map[string]string, map[string]error, error) {
+	entries, err := parseBatchEntries(body)
+	if err != nil && len(entries) == 0 {
+		return nil, nil, err
+	}
+	urls := make(map[string]string)
+	errs := make(map[string]error)
+	for _, entry := range entries {
+		if decodedURL, err := entry.garturlURL(); err != nil {
+			errs[entry.Tag] = err
+		} else {
+			urls[entry.Tag] = decodedURL
+		}
+	}
+	return urls, errs, nil
+}
+
+// parseDecodedURLResponse extracts the decoded URL from the response of a signed garturlreq call.
+// The call is untagged, so the first wrb.fr entry answers it.
+func parseDecodedURLResponse(body []byte) (string, error) {
+	entries, err := parseBatchEntries(body)
+	if len(entries) == 0 {
+		if err != nil {
+			return "", err
+		}
+		return "", errors.New("decoded URL not found in response")
+	}
+	return entries[0].garturlURL()
+}
@@ -0,0 +1,89 @@
+package gnewsdecoder_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	gnews "github.com/alainmucyo/google-news-url-decoder"
+)
+
+func TestBatchExecute_RejectsInvalidIDs(t *testing.T) {
+	rec := &headerRecorder{}
+	decoder, err := gnews.NewGoogleDecoder(gnews.WithTransport(rec))
+	if err != nil {
+		t.Fatalf("Failed to create GoogleDecoder: %v", err)
+	}
+
+	for _, id := range []string{"", `CBMi"],null,"x`, `CBMi\`, "CBMi+/=", "CBMi%22"} {
+		if params := decoder.GetDecodingParams(id); params.Status {
+			t.Errorf("GetDecodingParams(%q) status = true, want false", id)
+		}
+		if result := decoder.DecodeURL("sig", "1700000000", id); result.Status {
+			t.Errorf("DecodeURL(%q) status = true, want false", id)
+		}
+	}
+
+	if len(rec.headers) != 0 {
+		t.Errorf("Expected no requests for invalid IDs, got %d", len(rec.headers))
+	}
+}
+
+func TestBatchExecute_RejectsInvalidTimestamp(t *testing.T) {
+	rec := &headerRecorder{}
+	decoder, _ := gnews.NewGoogleDecoder(gnews.WithTransport(rec))
+
+	if result := decoder.DecodeURL("sig", `1,"injected"`, "CBMitest123"); result.Status {
+		t.Error("Expected Status to be false for a non-numeric timestamp")
+	}
+	if len(rec.headers) != 0 {
+		t.Errorf("Expected no requests for an invalid timestamp, got %d", len(rec.headers))
+	}
+}
+
+func TestBatchExecute_EscapesSignature(t *testing.T) {
+	var body string
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		b, _ := io.ReadAll(req.Body)
+		body = string(b)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader("")),
+			Request:    req,
+		}, nil
+	})
+	decoder, _ := gnews.NewGoogleDecoder(gnews.WithTransport(transport))
+
+	signature := `sig"],"evil\`
+	decoder.DecodeURL(signature, "1700000000", "CBMitest123")
+
+	form, err := url.ParseQuery(body)
+	if err != nil {
+		t.Fatalf("Failed to parse request body: %v", err)
+	}
+
+	var envelope [][][]string
+	if err := json.Unmarshal([]byte(form.Get("f.req")), &envelope); err != nil {
+		t.Fatalf("f.req is not valid JSON: %v", err)
+	}
+	if len(envelope) != 1 || len(envelope[0]) != 1 || len(envelope[0][0]) != 2 {
+		t.Fatalf("Unexpected envelope shape: %v", envelope)
+	}
+	if envelope[0][0][0] != "Fbv4je" {
+		t.Errorf("RPC ID = %q, want Fbv4je", envelope[0][0][0])
+	}
+
+	var args []any
+	if err := json.Unmarshal([]byte(envelope[0][0][1]), &args); err != nil {
+		t.Fatalf("garturlreq args are not valid JSON: %v", err)
+	}
+	if len(args) != 5 {
+		t.Fatalf("Expected 5 garturlreq args, got %d", len(args))
+	}
+	if args[2] != "CBMitest123" || args[3] != float64(1700000000) || args[4] != signature {
+		t.Errorf("Unexpected garturlreq args: %v", args[2:])
+	}
+}
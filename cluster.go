@@ -0,0 +1,138 @@
+package gnewsdecoder
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ClusterArticle is one article of a coverage cluster
+type ClusterArticle struct {
+	Title  string `json:"title"`
+	Source string `json:"source,omitempty"`
+	// Link is the Google News URL of the article
+	Link       string `json:"link"`
+	DecodedURL string `json:"decoded_url,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Cluster is the coverage of a story listed in the description of a Google News RSS item
+type Cluster struct {
+	Primary ClusterArticle   `json:"primary"`
+	Related []ClusterArticle `json:"related,omitempty"`
+	// FullCoverageURL is the "View Full Coverage" link, when present
+	FullCoverageURL string `json:"full_coverage_url,omitempty"`
+}
+
+// ParseDescription parses the HTML description of a Google News RSS item, an
+// <ol> of related articles or a single link, each followed by a <font> source
+// name. The first article is the primary one. Links are not decoded, see DecodeClusters.
+func ParseDescription(description string) (Cluster, error) {
+	var articles []ClusterArticle
+	var cluster Cluster
+
+	var current *ClusterArticle
+	var inAnchor, inFont bool
+	var text strings.Builder
+
+	z := html.NewTokenizer(strings.NewReader(description))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if !errors.Is(z.Err(), io.EOF) {
+				return Cluster{}, z.Err()
+			}
+			break
+		}
+
+		name, _ := z.TagName()
+		switch tt {
+		case html.StartTagToken:
+			switch string(name) {
+			case "a":
+				href := anchorHref(z)
+				if c, err := ClassifyURL(href); err == nil && c.Kind == URLStory {
+					cluster.FullCoverageURL = strings.TrimSpace(href)
+					current = nil
+					continue
+				}
+				articles = append(articles, ClusterArticle{Link: strings.TrimSpace(href)})
+				current = &articles[len(articles)-1]
+				inAnchor = true
+				text.Reset()
+			case "font":
+				if current != nil {
+					inFont = true
+					text.Reset()
+				}
+			}
+		case html.TextToken:
+			if inAnchor || inFont {
+				text.Write(z.Text())
+			}
+		case html.EndTagToken:
+			switch {
+			case string(name) == "a" && inAnchor:
+				current.Title = collapseSpace(text.String())
+				inAnchor = false
+			case string(name) == "font" && inFont:
+				current.Source = collapseSpace(text.String())
+				inFont = false
+			}
+		}
+	}
+
+	if len(articles) == 0 {
+		return Cluster{}, errors.New("no articles in description")
+	}
+	cluster.Primary = articles[0]
+	cluster.Related = articles[1:]
+	if len(cluster.Related) == 0 {
+		cluster.Related = nil
+	}
+	return cluster, nil
+}
+
+// DecodeClusters decodes the link of every article of the clusters in place,
+// with DecodeInto. Links shared by several articles are decoded once.
+// Failures are reported in ClusterArticle.Error.
+func DecodeClusters(ctx context.Context, decoder *GoogleDecoder, clusters []Cluster) {
+	var articles []*ClusterArticle
+	for i := range clusters {
+		articles = append(articles, &clusters[i].Primary)
+		for j := range clusters[i].Related {
+			articles = append(articles, &clusters[i].Related[j])
+		}
+	}
+
+	DecodeInto(ctx, decoder, articles,
+		func(article *ClusterArticle) string { return article.Link },
+		func(article **ClusterArticle, result DecodeResult) {
+			if result.Status {
+				(*article).DecodedURL, (*article).Error = result.DecodedURL, ""
+			} else {
+				(*article).DecodedURL, (*article).Error = "", result.Message
+			}
+		})
+}
+
+// anchorHref returns the href attribute of the current tag
+func anchorHref(z *html.Tokenizer) string {
+	for {
+		key, val, more := z.TagAttr()
+		if string(key) == "href" {
+			return string(val)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// collapseSpace trims and collapses whitespace, including the &nbsp; Google puts between titles and sources
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,59 @@
+package gnewsdecoder_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	gnews "github.com/alainmucyo/google-news-url-decoder"
+)
+
+func TestParseDescription(t *testing.T) {
+	first, _ := gnews.EncodeArticleURL("https://www.bbc.com/news/story", gnews.EncodeOptions{})
+	second, _ := gnews.EncodeArticleURL("https://www.reuters.com/world/story", gnews.EncodeOptions{})
+	third := "https://news.google.com/rss/articles/CBMiunknown?oc=5"
+
+	description := fmt.Sprintf(`<ol><li><a href="%s?oc=5" target="_blank">Leaders meet in Geneva</a>&nbsp;&nbsp;<font color="#6f6f6f">BBC</font></li>`+
+		`<li><a href="%s?oc=5" target="_blank">Geneva summit &amp; what's next</a>&nbsp;&nbsp;<font color="#6f6f6f">Reuters</font></li>`+
+		`<li><a href="%s" target="_blank">Summit live</a>&nbsp;&nbsp;<font color="#6f6f6f">Example News</font></li>`+
+		`<li><strong><a href="https://news.google.com/stories/CAAqNggKIjBDQklTSGpvSmMzUnZjbmt0TXpZd1NoRUtEd2pXdGZiR0NSSGF0cVc3MEl5ZEVTZ0FQAQ?oc=5" target="_blank">View Full Coverage on Google News</a></strong></li></ol>`,
+		first, second, third)
+
+	cluster, err := gnews.ParseDescription(description)
+	if err != nil {
+		t.Fatalf("ParseDescription() error = %v", err)
+	}
+	if cluster.Primary.Title != "Leaders meet in Geneva" || cluster.Primary.Source != "BBC" || cluster.Primary.Link != first+"?oc=5" {
+		t.Errorf("Primary = %+v", cluster.Primary)
+	}
+	if len(cluster.Related) != 2 || cluster.Related[0].Title != "Geneva summit & what's next" || cluster.Related[1].Source != "Example News" {
+		t.Errorf("Related = %+v", cluster.Related)
+	}
+	if cluster.FullCoverageURL == "" {
+		t.Error("FullCoverageURL not found")
+	}
+
+	decoder, _ := gnews.NewGoogleDecoder(gnews.WithOfflineOnly())
+	clusters := []gnews.Cluster{cluster}
+	gnews.DecodeClusters(context.Background(), decoder, clusters)
+
+	if got := clusters[0].Primary.DecodedURL; got != "https://www.bbc.com/news/story" {
+		t.Errorf("Primary.DecodedURL = %q", got)
+	}
+	if got := clusters[0].Related[0].DecodedURL; got != "https://www.reuters.com/world/story" {
+		t.Errorf("Related[0].DecodedURL = %q", got)
+	}
+	if related := clusters[0].Related[1]; related.DecodedURL != "" || related.Error == "" {
+		t.Errorf("Related[1] = %+v, want error", related)
+	}
+
+	// Single-article descriptions have no <ol>
+	single, err := gnews.ParseDescription(fmt.Sprintf(`<a href="%s" target="_blank">Only story</a>&nbsp;&nbsp;<font color="#6f6f6f">BBC</font>`, first))
+	if err != nil || single.Primary.Title != "Only story" || single.Related != nil {
+		t.Errorf("ParseDescription(single) = %+v, %v", single, err)
+	}
+
+	if _, err := gnews.ParseDescription("no links here"); err == nil {
+		t.Error("Expected error for a description without articles")
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/alainmucyo/google-news-url-decoder/alerts"
+)
+
+// runAlerts implements the alerts subcommand and returns the exit code
+func runAlerts(args []string) int {
+	fs := flag.NewFlagSet("alerts", flag.ExitOnError)
+	jsonOutput := fs.Bool("json", false, "Output entries as JSON")
+	var df decoderFlags
+	df.register(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s alerts [flags] <feed-url-or-file>\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Prints the publisher URLs of a Google Alerts Atom or RSS feed.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 1
+	}
+
+	decoder, err := df.newDecoder()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	ctx := context.Background()
+	feed, err := openInput(ctx, decoder, fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	defer feed.Close()
+
+	entries, err := alerts.NewResolver(decoder).ResolveFeed(ctx, feed)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(entries); err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+			return 1
+		}
+	}
+
+	exitCode := 0
+	for _, entry := range entries {
+		if entry.Error != "" {
+			fmt.Fprintf(os.Stderr, "Error: %s: %s\n", entry.Link, entry.Error)
+			exitCode = 1
+			continue
+		}
+		if !*jsonOutput {
+			fmt.Println(entry.URL)
+		}
+	}
+	return exitCode
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	gnews "github.com/alainmucyo/google-news-url-decoder"
+)
+
+// runFeed implements the feed subcommand and returns the exit code
+func runFeed(args []string) int {
+	fs := flag.NewFlagSet("feed", flag.ExitOnError)
+	output := fs.String("o", "", "Write the feed to this file instead of stdout; with -opml, the directory to write the feeds to")
+	opmlFile := fs.String("opml", "", "Rewrite every feed of an OPML subscription list; outline titles and categories become item categories")
+	formatName := fs.String("format", "rss", "Output format: rss, atom or jsonfeed")
+	var df decoderFlags
+	df.register(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s feed [flags] <feed-url-or-file>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s feed -opml <file> -o <dir> [flags]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Rewrites a Google News RSS feed with publisher URLs in every item link.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if (*opmlFile == "" && fs.NArg() != 1) || (*opmlFile != "" && (fs.NArg() != 0 || *output == "")) {
+		fs.Usage()
+		return 1
+	}
+
+	format, err := gnews.ParseFeedFormat(*formatName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	decoder, err := df.newDecoder()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	ctx := context.Background()
+
+	if *opmlFile != "" {
+		return rewriteOPMLFeeds(ctx, decoder, *opmlFile, *output, format)
+	}
+
+	failed, total, err := rewriteFeed(ctx, decoder, fs.Arg(0), *output, format, nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %d of %d item links could not be decoded and were left unchanged\n", failed, total)
+	}
+	return 0
+}
+
+// rewriteOPMLFeeds rewrites every feed of an OPML list into dir, one file per feed named after its title
+func rewriteOPMLFeeds(ctx context.Context, decoder *gnews.GoogleDecoder, opmlFile, dir string, format gnews.FeedFormat) int {
+	feeds, err := loadFeeds("", opmlFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	exitCode := 0
+	names := make(map[string]bool)
+	for i, feed := range feeds {
+		name := fileName(feed.Title, i+1, format, names)
+		categories := feed.Categories()
+		failed, total, err := rewriteFeed(ctx, decoder, feed.URL, filepath.Join(dir, name), format, categories)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s: %v\n", feed.URL, err)
+			exitCode = 1
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "%s: %d of %d item links decoded [%s]\n", name, total-failed, total, strings.Join(categories, ", "))
+	}
+	return exitCode
+}
+
+// rewriteFeed rewrites the feed at input to output, stdout when empty,
+// and returns how many of its item links failed to decode. categories are
+// added to every item. RSS feeds without them are rewritten in place, other
+// feeds are generated from the parsed feed.
+func rewriteFeed(ctx context.Context, decoder *gnews.GoogleDecoder, input, output string, format gnews.FeedFormat, categories []string) (failed, total int, err error) {
+	in, err := openInput(ctx, decoder, input)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer in.Close()
+
+	out := os.Stdout
+	if output != "" {
+		out, err = os.Create(output)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	w := bufio.NewWriter(out)
+
+	if format == gnews.FormatRSS && len(categories) == 0 {
+		var results []gnews.DecodeResult
+		results, err = gnews.RewriteFeedWithContext(ctx, in, w, decoder)
+		for _, result := range results {
+			if !result.Status {
+				failed++
+			}
+		}
+		total = len(results)
+	} else {
+		failed, total, err = convertFeed(ctx, decoder, in, w, format, categories)
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if out != os.Stdout {
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return failed, total, err
+}
+
+// convertFeed parses an RSS feed, decodes its links, adds categories to its
+// items and writes it in format
+func convertFeed(ctx context.Context, decoder *gnews.GoogleDecoder, r io.Reader, w io.Writer, format gnews.FeedFormat, categories []string) (failed, total int, err error) {
+	feed, err := gnews.ParseFeed(r)
+	if err != nil {
+		return 0, 0, err
+	}
+	gnews.DecodeFeedItems(ctx, decoder, feed.Items)
+
+	for i, item := range feed.Items {
+		feed.Items[i].Categories = append(item.Categories, categories...)
+		if item.Error != "" {
+			failed++
+		}
+	}
+	return failed, len(feed.Items), gnews.WriteFeed(w, feed, format)
+}
+
+// fileName returns a unique file name derived from title, with the extension of format
+func fileName(title string, n int, format gnews.FeedFormat, used map[string]bool) string {
+	base := strings.Join(strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), "-")
+	if base == "" {
+		base = fmt.Sprintf("feed-%d", n)
+	}
+
+	ext := ".xml"
+	if format == gnews.FormatJSONFeed {
+		ext = ".json"
+	}
+	name := base + ext
+	for i := 2; used[name]; i++ {
+		name = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+	used[name] = true
+	return name
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	gnews "github.com/alainmucyo/google-news-url-decoder"
+	"github.com/alainmucyo/google-news-url-decoder/gnewstest"
+)
+
+func TestRewriteOPMLFeeds_Categories(t *testing.T) {
+	srv := gnewstest.NewServer()
+	defer srv.Close()
+
+	id, _ := gnews.EncodeArticleID("AU_yqLopmlcli", gnews.EncodeOptions{})
+	srv.AddArticle(id, "https://example.com/story")
+	srv.AddFeed("/rss/search", `<rss version="2.0"><channel><title>Go</title>`+
+		`<item><title>Story</title><link>https://news.google.com/rss/articles/`+id+`?oc=5</link>`+
+		`<guid isPermaLink="false">`+id+`</guid><description>&lt;a href="x"&gt;Story&lt;/a&gt;</description></item>`+
+		`</channel></rss>`)
+
+	dir := t.TempDir()
+	opmlFile := filepath.Join(dir, "subscriptions.opml")
+	opml := `<?xml version="1.0"?><opml version="2.0"><body><outline text="Tech">` +
+		`<outline text="Go news" type="rss" xmlUrl="` + srv.URL + `/rss/search?q=go" category="Weekly"/>` +
+		`</outline></body></opml>`
+	if err := os.WriteFile(opmlFile, []byte(opml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	decoder, err := gnews.NewGoogleDecoder(srv.Option())
+	if err != nil {
+		t.Fatalf("Failed to create GoogleDecoder: %v", err)
+	}
+
+	tests := []struct {
+		format gnews.FeedFormat
+		file   string
+		want   []string
+	}{
+		{gnews.FormatRSS, "go-news.xml", []string{"<category>Go news</category>", "<category>Tech</category>", "<category>Weekly</category>", "<link>https://example.com/story</link>", "<description>"}},
+		{gnews.FormatAtom, "go-news.xml", []string{`<category term="Go news"></category>`, `<category term="Tech"></category>`, `<category term="Weekly"></category>`}},
+		{gnews.FormatJSONFeed, "go-news.json", []string{`"tags": [`, `"Go news"`, `"Tech"`, `"Weekly"`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			out := filepath.Join(dir, string(tt.format))
+			if code := rewriteOPMLFeeds(context.Background(), decoder, opmlFile, out, tt.format); code != 0 {
+				t.Fatalf("rewriteOPMLFeeds() = %d, want 0", code)
+			}
+			data, err := os.ReadFile(filepath.Join(out, tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(data), want) {
+					t.Errorf("%s output is missing %s:\n%s", tt.format, want, data)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	gnews "github.com/alainmucyo/google-news-url-decoder"
+)
+
+// runInspect implements the inspect subcommand and returns the exit code
+func runInspect(args []string) int {
+	fs := flag.NewFlagSet("inspect", flag.ExitOnError)
+	jsonOutput := fs.Bool("json", false, "Output results as JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s inspect [flags] <url-or-id> [...]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Dumps the structure of article IDs without any network access.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 1
+	}
+
+	exitCode := 0
+	var infos []gnews.ArticleIDInfo
+	for _, arg := range fs.Args() {
+		info, err := gnews.InspectArticleID(arg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s: %v\n", arg, err)
+			exitCode = 1
+			continue
+		}
+		infos = append(infos, info)
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(infos); err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+			return 1
+		}
+		return exitCode
+	}
+
+	for i, info := range infos {
+		if i > 0 {
+			fmt.Println()
+		}
+		printInspection(info)
+	}
+	return exitCode
+}
+
+func printInspection(info gnews.ArticleIDInfo) {
+	method := func(m gnews.DecodeMethod) string {
+		if m == "" {
+			return "none"
+		}
+		return string(m)
+	}
+	offline := "no"
+	if info.OfflineDecodable {
+		offline = "yes"
+	}
+
+	fmt.Printf("ID:      %s\n", info.ID)
+	fmt.Printf("Base64:  %s\n", info.Base64)
+	fmt.Printf("Hex:     %s\n", info.Hex)
+	fmt.Printf("Kind:    %s\n", info.Kind)
+	fmt.Printf("Method:  %s (-batch: %s)\n", method(info.Method), method(info.BatchMethod))
+	fmt.Printf("Offline: %s\n", offline)
+	if info.Error != "" {
+		fmt.Printf("Error:   %s\n", info.Error)
+	}
+	fmt.Println("Fields:")
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, field := range info.Fields {
+		value := field.Value
+		if field.Encoding != "" {
+			value = field.Encoding + ":" + value
+		}
+		fmt.Fprintf(w, "  %d\t%s\t%s\n", field.Number, field.WireType, value)
+	}
+	w.Flush()
+}
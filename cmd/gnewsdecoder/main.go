@@ -3,36 +3,80 @@
 // Usage:
 //
 //	gnewsdecoder [flags] <url> [urls...]
+//	gnewsdecoder inspect [-json] <url-or-id> [...]
+//	gnewsdecoder alerts [flags] <feed-url-or-file>
+//	gnewsdecoder feed [flags] <feed-url-or-file>
+//	gnewsdecoder watch {-feeds <file> | -opml <file>} [flags]
+//	gnewsdecoder opml export [flags]
+//	gnewsdecoder rewrite [flags] [file]
+//	gnewsdecoder rewrite-json -path <jsonpath> [flags] [file]
 //
 // Example:
 //
 //	gnewsdecoder "https://news.google.com/read/CBMi..."
 //	gnewsdecoder -proxy "http://localhost:8080" "https://news.google.com/read/CBMi..."
 //	gnewsdecoder -batch "https://news.google.com/read/CBMi..." "https://news.google.com/read/CBMi..."
+//	gnewsdecoder -ca-file corp-ca.pem "https://news.google.com/read/CBMi..."
+//	gnewsdecoder inspect "https://news.google.com/rss/articles/CBMi..."
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	gnews "github.com/alainmucyo/google-news-url-decoder"
 )
 
 func main() {
+	// Subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "inspect":
+			os.Exit(runInspect(os.Args[2:]))
+		case "alerts":
+			os.Exit(runAlerts(os.Args[2:]))
+		case "feed":
+			os.Exit(runFeed(os.Args[2:]))
+		case "watch":
+			os.Exit(runWatch(os.Args[2:]))
+		case "opml":
+			os.Exit(runOPML(os.Args[2:]))
+		case "rewrite":
+			os.Exit(runRewrite(os.Args[2:]))
+		case "rewrite-json":
+			os.Exit(runRewriteJSON(os.Args[2:]))
+		}
+	}
+
 	// Flags
-	proxyURL := flag.String("proxy", "", "Proxy URL (http://host:port or socks5://host:port)")
 	intervalSec := flag.Int("interval", 0, "Interval in seconds between requests to avoid rate limits")
 	batchMode := flag.Bool("batch", false, "Use batch mode for multiple URLs (more efficient)")
 	concurrent := flag.Int("concurrent", 0, "Number of concurrent workers (0 = sequential)")
 	jsonOutput := flag.Bool("json", false, "Output results as JSON")
+	formatName := flag.String("format", "", "Output results as a feed: rss, atom or jsonfeed")
+	var df decoderFlags
+	df.register(flag.CommandLine)
 	version := flag.Bool("version", false, "Print version and exit")
 
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Google News URL Decoder - Decode Google News URLs to original source URLs\n\n")
-		fmt.Fprintf(os.Stderr, "Usage: %s [flags] <url> [urls...]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] <url> [urls...]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s inspect [-json] <url-or-id> [...]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s alerts [flags] <feed-url-or-file>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s feed [flags] <feed-url-or-file>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s watch {-feeds <file> | -opml <file>} [flags]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s opml export [flags]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s rewrite [flags] [file]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s rewrite-json -path <jsonpath> [flags] [file]\n\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "Flags:\n")
 		flag.PrintDefaults()
 		fmt.Fprintf(os.Stderr, "\nExamples:\n")
@@ -40,6 +84,13 @@ func main() {
 		fmt.Fprintf(os.Stderr, "  %s -proxy \"http://localhost:8080\" \"https://news.google.com/read/CBMi...\"\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -batch \"https://news.google.com/read/CBMi...\" \"https://news.google.com/read/CBMi...\"\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -concurrent 5 \"https://news.google.com/read/CBMi...\" \"https://news.google.com/read/CBMi...\"\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s inspect \"https://news.google.com/rss/articles/CBMi...\"\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s alerts \"https://www.google.com/alerts/feeds/.../...\"\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s feed \"https://news.google.com/rss/search?q=golang\"\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s watch -feeds feeds.txt -every 5m -o articles.ndjson\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s opml export -opml subscriptions.opml -state gnewsdecoder-watch.json\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s rewrite -w notes.md page.html\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s rewrite-json -path '$.items[*].link' -into decoded_link items.ndjson\n", os.Args[0])
 	}
 
 	flag.Parse()
@@ -55,6 +106,16 @@ func main() {
 		os.Exit(1)
 	}
 
+	var format gnews.FeedFormat
+	if *formatName != "" {
+		f, err := gnews.ParseFeedFormat(*formatName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		format = f
+	}
+
 	// Prepare interval
 	var interval *time.Duration
 	if *intervalSec > 0 {
@@ -62,10 +123,10 @@ func main() {
 		interval = &d
 	}
 
-	// Prepare proxy
-	var proxy *string
-	if *proxyURL != "" {
-		proxy = proxyURL
+	decoder, err := df.newDecoder()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
 
 	var results []gnews.DecodeResult
@@ -73,28 +134,178 @@ func main() {
 	switch {
 	case *batchMode && len(args) > 1:
 		// Batch mode
-		results = gnews.GNewsDecoderBatch(args)
+		results = decoder.DecodeBatch(args)
 
 	case *concurrent > 0:
 		// Concurrent mode
-		results = gnews.GNewsDecoderConcurrent(args, *concurrent, interval, proxy)
+		results = gnews.NewConcurrentDecoder(decoder, *concurrent).DecodeURLs(args, interval)
 
 	default:
 		// Sequential mode
 		for _, url := range args {
-			result := gnews.GNewsDecoder(url, interval, proxy)
+			result := decoder.Decode(url, interval)
 			results = append(results, result)
 		}
 	}
 
 	// Output results
-	if *jsonOutput {
+	if format != "" {
+		outputFeed(args, results, format)
+	} else if *jsonOutput {
 		outputJSON(results)
 	} else {
 		outputText(args, results)
 	}
 }
 
+// decoderFlags holds the flags configuring the decoder, shared by all commands
+type decoderFlags struct {
+	proxyURL           string
+	caFile             string
+	insecureSkipVerify bool
+	userAgent          string
+	profilesFile       string
+	profileRotation    string
+	offline            bool
+	locale             string
+	headers            headerFlags
+}
+
+func (f *decoderFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.proxyURL, "proxy", "", "Proxy URL (http://host:port, socks5://host:port or socks5h://host:port); defaults to HTTP(S)_PROXY")
+	fs.StringVar(&f.caFile, "ca-file", "", "PEM file with additional CA certificates to trust (e.g. a corporate proxy CA)")
+	fs.BoolVar(&f.insecureSkipVerify, "insecure-skip-verify", false, "Disable TLS certificate verification (INSECURE, for debugging only)")
+	fs.StringVar(&f.userAgent, "user-agent", "", "User-Agent header to send (overrides browser profiles)")
+	fs.StringVar(&f.profilesFile, "profiles", "", "JSON file with browser profiles to rotate through")
+	fs.StringVar(&f.profileRotation, "profile-rotation", "session", "Browser profile rotation: session or request")
+	fs.BoolVar(&f.offline, "offline", false, "Never access the network; IDs that need Google to resolve fail")
+	fs.StringVar(&f.locale, "locale", "", "Edition as ceid, e.g. FR:fr (default: taken from each URL's hl/gl/ceid)")
+	fs.Var(&f.headers, "header", "Extra request header as \"Name: value\" (repeatable)")
+}
+
+// newDecoder builds the decoder configured by the flags
+func (f *decoderFlags) newDecoder() (*gnews.GoogleDecoder, error) {
+	var opts []gnews.DecoderOption
+	if f.proxyURL != "" {
+		opts = append(opts, gnews.WithProxy(f.proxyURL))
+	}
+
+	if f.offline {
+		opts = append(opts, gnews.WithOfflineOnly())
+	}
+
+	if f.locale != "" {
+		opts = append(opts, gnews.WithLocale(gnews.Locale{Edition: f.locale}))
+	}
+
+	tlsConfig, err := buildTLSConfig(f.caFile, f.insecureSkipVerify)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		opts = append(opts, gnews.WithTLSConfig(tlsConfig))
+	}
+
+	identityOpts, err := buildIdentityOptions(f.userAgent, f.headers, f.profilesFile, f.profileRotation)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, identityOpts...)
+
+	return gnews.NewGoogleDecoder(opts...)
+}
+
+// headerFlags collects repeated -header flags
+type headerFlags []string
+
+func (h *headerFlags) String() string {
+	return strings.Join(*h, ", ")
+}
+
+func (h *headerFlags) Set(value string) error {
+	if !strings.Contains(value, ":") {
+		return fmt.Errorf("header must be \"Name: value\", got %q", value)
+	}
+	*h = append(*h, value)
+	return nil
+}
+
+// buildIdentityOptions returns the decoder options for the request identity flags
+func buildIdentityOptions(userAgent string, headers headerFlags, profilesFile, rotation string) ([]gnews.DecoderOption, error) {
+	var opts []gnews.DecoderOption
+
+	if userAgent != "" {
+		opts = append(opts, gnews.WithUserAgent(userAgent))
+	}
+
+	if len(headers) > 0 {
+		h := http.Header{}
+		for _, header := range headers {
+			name, value, _ := strings.Cut(header, ":")
+			h.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+		}
+		opts = append(opts, gnews.WithHeaders(h))
+	}
+
+	if profilesFile != "" {
+		f, err := os.Open(profilesFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open profiles file: %v", err)
+		}
+		defer f.Close()
+
+		profiles, err := gnews.LoadBrowserProfiles(f)
+		if err != nil {
+			return nil, err
+		}
+
+		var r gnews.ProfileRotation
+		switch rotation {
+		case "session":
+			r = gnews.RotatePerSession
+		case "request":
+			r = gnews.RotatePerRequest
+		default:
+			return nil, fmt.Errorf("unknown profile rotation %q (want session or request)", rotation)
+		}
+		opts = append(opts, gnews.WithBrowserProfiles(profiles, r))
+	}
+
+	return opts, nil
+}
+
+// buildTLSConfig returns the TLS configuration for the CLI flags, or nil if none is needed
+func buildTLSConfig(caFile string, insecureSkipVerify bool) (*tls.Config, error) {
+	if caFile == "" && !insecureSkipVerify {
+		return nil, nil
+	}
+
+	config := &tls.Config{}
+
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %v", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in %s", caFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if insecureSkipVerify {
+		fmt.Fprintln(os.Stderr, "WARNING: TLS certificate verification is DISABLED (-insecure-skip-verify).")
+		fmt.Fprintln(os.Stderr, "WARNING: Anyone on the network path can read and alter this traffic. Use -ca-file instead.")
+		config.InsecureSkipVerify = true
+	}
+
+	return config, nil
+}
+
 func outputJSON(results []gnews.DecodeResult) {
 	encoder := json.NewEncoder(os.Stdout)
 	encoder.SetIndent("", "  ")
@@ -104,6 +315,23 @@ func outputJSON(results []gnews.DecodeResult) {
 	}
 }
 
+// outputFeed writes the results as a feed, with one item per URL
+func outputFeed(urls []string, results []gnews.DecodeResult, format gnews.FeedFormat) {
+	feed := &gnews.Feed{Title: "Decoded Google News links"}
+	for i, result := range results {
+		feed.Items = append(feed.Items, gnews.FeedItem{
+			Title:      urls[i],
+			Link:       urls[i],
+			DecodedURL: result.DecodedURL,
+			Error:      result.Message,
+		})
+	}
+	if err := gnews.WriteFeed(os.Stdout, feed, format); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
 func outputText(urls []string, results []gnews.DecodeResult) {
 	exitCode := 0
 	for i, result := range results {
@@ -124,3 +352,24 @@ func outputText(urls []string, results []gnews.DecodeResult) {
 	}
 	os.Exit(exitCode)
 }
+
+// openInput opens a local file, or fetches an http(s) URL through the decoder
+func openInput(ctx context.Context, decoder *gnews.GoogleDecoder, input string) (io.ReadCloser, error) {
+	if !strings.HasPrefix(input, "http://") && !strings.HasPrefix(input, "https://") {
+		return os.Open(input)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, input, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := decoder.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("fetching %s failed with status: %d", input, resp.StatusCode)
+	}
+	return resp.Body, nil
+}
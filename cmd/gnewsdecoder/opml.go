@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/alainmucyo/google-news-url-decoder/watch"
+)
+
+// runOPML implements the opml subcommand and returns the exit code
+func runOPML(args []string) int {
+	if len(args) == 0 || args[0] != "export" {
+		fmt.Fprintf(os.Stderr, "Usage: %s opml export [flags]\n", os.Args[0])
+		return 1
+	}
+
+	fs := flag.NewFlagSet("opml export", flag.ExitOnError)
+	feedsFile := fs.String("feeds", "", "File listing feed URLs, one per line")
+	opmlFile := fs.String("opml", "", "OPML subscription list")
+	statePath := fs.String("state", "", "Watch state file to take decoding metadata from")
+	title := fs.String("title", "Google News feeds", "Title of the exported list")
+	output := fs.String("o", "", "Write the list to this file instead of stdout")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s opml export [-feeds <file>] [-opml <file>] [flags]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Writes the feed list as OPML, with the edition and watch state of every feed.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args[1:])
+
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return 1
+	}
+
+	feeds, err := loadFeeds(*feedsFile, *opmlFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	var store watch.Store
+	if *statePath != "" {
+		if _, err := os.Stat(*statePath); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		if store, err = watch.OpenFileStore(*statePath); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		out, err = os.Create(*output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+	err = watch.WriteOPML(out, *title, feeds, store)
+	if out != os.Stdout {
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// loadFeeds reads the feeds of a URL list and of an OPML subscription list,
+// at least one of which must be given. Feeds listed in both are kept once.
+func loadFeeds(feedsFile, opmlFile string) ([]watch.Feed, error) {
+	if feedsFile == "" && opmlFile == "" {
+		return nil, errors.New("no feeds given, use -feeds or -opml")
+	}
+
+	var feeds []watch.Feed
+	for _, source := range []struct {
+		path string
+		read func(*os.File) ([]watch.Feed, error)
+	}{
+		{feedsFile, func(f *os.File) ([]watch.Feed, error) { return watch.ReadFeedList(f) }},
+		{opmlFile, func(f *os.File) ([]watch.Feed, error) { return watch.ReadOPML(f) }},
+	} {
+		if source.path == "" {
+			continue
+		}
+		f, err := os.Open(source.path)
+		if err != nil {
+			return nil, err
+		}
+		list, err := source.read(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", source.path, err)
+		}
+		feeds = append(feeds, list...)
+	}
+
+	seen := make(map[string]bool)
+	unique := feeds[:0]
+	for _, feed := range feeds {
+		if !seen[feed.URL] {
+			seen[feed.URL] = true
+			unique = append(unique, feed)
+		}
+	}
+	return unique, nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gnews "github.com/alainmucyo/google-news-url-decoder"
+)
+
+// rewriters maps -format values to the library functions
+var rewriters = map[string]func(context.Context, io.Reader, io.Writer, *gnews.GoogleDecoder) ([]gnews.LinkResult, error){
+	"html":     gnews.RewriteHTML,
+	"markdown": gnews.RewriteMarkdown,
+	"text":     gnews.RewriteText,
+}
+
+// runRewrite implements the rewrite subcommand and returns the exit code
+func runRewrite(args []string) int {
+	fs := flag.NewFlagSet("rewrite", flag.ExitOnError)
+	formatName := fs.String("format", "", "Input format: html, markdown or text (default: from the file extension, text for stdin)")
+	output := fs.String("o", "", "Write the result to this file instead of stdout")
+	inPlace := fs.Bool("w", false, "Rewrite the files in place")
+	reportFile := fs.String("report", "", "Write the result of every link as JSON to this file")
+	var df decoderFlags
+	df.register(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s rewrite [flags] [file]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s rewrite -w [flags] <file> [files...]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Replaces the Google News links of HTML, Markdown or text with publisher URLs.\nLinks that cannot be decoded are left unchanged and reported.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *formatName != "" && rewriters[*formatName] == nil {
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q, want html, markdown or text\n", *formatName)
+		return 1
+	}
+	if (*inPlace && (fs.NArg() == 0 || *output != "")) || (!*inPlace && fs.NArg() > 1) {
+		fs.Usage()
+		return 1
+	}
+
+	decoder, err := df.newDecoder()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	ctx := context.Background()
+
+	inputs := fs.Args()
+	if len(inputs) == 0 {
+		inputs = []string{"-"}
+	}
+
+	exitCode := 0
+	var report []gnews.LinkResult
+	for _, input := range inputs {
+		target := *output
+		if *inPlace {
+			target = input
+		}
+		results, err := rewriteFile(ctx, decoder, input, target, *formatName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s: %v\n", input, err)
+			exitCode = 1
+			continue
+		}
+		for _, result := range results {
+			if result.Error != "" {
+				fmt.Fprintf(os.Stderr, "Warning: %s: %s left unchanged: %s\n", input, result.Link, result.Error)
+			}
+		}
+		report = append(report, results...)
+	}
+
+	if *reportFile != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err == nil {
+			err = os.WriteFile(*reportFile, append(data, '\n'), 0o644)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+	return exitCode
+}
+
+// rewriteFile rewrites input, "-" for stdin, to output, stdout when empty.
+// The output is only written once the whole input was rewritten.
+func rewriteFile(ctx context.Context, decoder *gnews.GoogleDecoder, input, output, format string) ([]gnews.LinkResult, error) {
+	in := os.Stdin
+	if input != "-" {
+		f, err := os.Open(input)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	if format == "" {
+		format = formatFromExtension(input)
+	}
+
+	var buf bytes.Buffer
+	results, err := rewriters[format](ctx, in, &buf, decoder)
+	if err != nil {
+		return nil, err
+	}
+
+	if output == "" {
+		_, err = buf.WriteTo(os.Stdout)
+	} else {
+		mode := os.FileMode(0o644)
+		if info, serr := os.Stat(output); serr == nil {
+			mode = info.Mode().Perm()
+		}
+		err = os.WriteFile(output, buf.Bytes(), mode)
+	}
+	return results, err
+}
+
+// formatFromExtension guesses the format of a file from its name
+func formatFromExtension(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".html", ".htm", ".xhtml":
+		return "html"
+	case ".md", ".markdown", ".mdown":
+		return "markdown"
+	default:
+		return "text"
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	gnews "github.com/alainmucyo/google-news-url-decoder"
+	"github.com/alainmucyo/google-news-url-decoder/watch"
+)
+
+// runWatch implements the watch subcommand and returns the exit code
+func runWatch(args []string) int {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	feedsFile := fs.String("feeds", "", "File listing the feed URLs to poll, one per line")
+	opmlFile := fs.String("opml", "", "OPML subscription list of the feeds to poll; titles and categories become article tags")
+	every := fs.Duration("every", watch.DefaultInterval, "Time between two polls of a feed")
+	jitter := fs.Float64("jitter", watch.DefaultJitter, "Fraction of the interval poll times are spread by")
+	statePath := fs.String("state", "gnewsdecoder-watch.json", "File remembering seen articles and feed validators")
+	output := fs.String("o", "", "Append articles to this file instead of writing them to stdout (feed formats replace it)")
+	once := fs.Bool("once", false, "Poll every feed once and exit, e.g. from cron")
+	formatName := fs.String("format", "ndjson", "Output format: ndjson, or with -once rss, atom or jsonfeed")
+	var df decoderFlags
+	df.register(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s watch {-feeds <file> | -opml <file>} [flags]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Polls Google News RSS feeds and writes newly seen articles as NDJSON.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if (*feedsFile == "" && *opmlFile == "") || fs.NArg() != 0 {
+		fs.Usage()
+		return 1
+	}
+
+	var format gnews.FeedFormat
+	if *formatName != "ndjson" {
+		f, err := gnews.ParseFeedFormat(*formatName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		if !*once {
+			fmt.Fprintf(os.Stderr, "Error: -format %s requires -once\n", f)
+			return 1
+		}
+		format = f
+	}
+
+	feeds, err := loadFeeds(*feedsFile, *opmlFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	decoder, err := df.newDecoder()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	store, err := watch.OpenFileStore(*statePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	// NDJSON is appended, feed documents replace the file
+	out := os.Stdout
+	if *output != "" {
+		mode := os.O_APPEND
+		if format != "" {
+			mode = os.O_TRUNC
+		}
+		out, err = os.OpenFile(*output, os.O_WRONLY|os.O_CREATE|mode, 0o644)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		defer out.Close()
+	}
+
+	w := watch.New(decoder, store,
+		watch.WithInterval(*every),
+		watch.WithJitter(*jitter),
+		watch.WithErrorHandler(func(feed watch.Feed, err error) {
+			fmt.Fprintf(os.Stderr, "%s Error: %s: %v\n", time.Now().Format(time.RFC3339), feed.URL, err)
+		}),
+	)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// One line per article, written as soon as it is decoded
+	encoder := json.NewEncoder(out)
+	emit := func(a watch.Article) error {
+		return encoder.Encode(a)
+	}
+
+	// Feed formats collect the articles into one document
+	collected := &gnews.Feed{Title: "New Google News articles"}
+	if format != "" {
+		emit = func(a watch.Article) error {
+			collected.Items = append(collected.Items, a.FeedItem())
+			return nil
+		}
+	}
+
+	if *once {
+		err = w.PollAll(ctx, feeds, emit)
+	} else {
+		err = w.Run(ctx, feeds, emit)
+	}
+	if err == nil && format != "" {
+		err = gnews.WriteFeed(out, collected, format)
+	}
+	if err != nil && !errors.Is(err, context.Canceled) {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
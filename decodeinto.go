@@ -0,0 +1,58 @@
+package gnewsdecoder
+
+import "context"
+
+// DecodeInto decodes the Google News URL of every item and stores the result
+// in the item. get returns the URL of an item; items for which it returns an
+// empty string are skipped. set is called once per decoded item, in order,
+// with a pointer into items.
+//
+// URLs are decoded with a ConcurrentDecoder, like RewriteFeed does. Each
+// article ID is decoded once per edition, however its URL is written.
+//
+// Example:
+//
+//	gnews.DecodeInto(ctx, decoder, articles,
+//		func(a Article) string { return a.GoogleURL },
+//		func(a *Article, r gnews.DecodeResult) { a.URL = r.DecodedURL })
+func DecodeInto[T any](ctx context.Context, d *GoogleDecoder, items []T, get func(T) string, set func(*T, DecodeResult)) {
+	var links []string
+	index := make(map[string]int)
+	itemLinks := make([]int, len(items))
+	for i, item := range items {
+		link := get(item)
+		if link == "" {
+			itemLinks[i] = -1
+			continue
+		}
+		key := d.decodeKey(link)
+		j, ok := index[key]
+		if !ok {
+			j = len(links)
+			index[key] = j
+			links = append(links, link)
+		}
+		itemLinks[i] = j
+	}
+	if len(links) == 0 {
+		return
+	}
+
+	results := NewConcurrentDecoder(d, 0).DecodeURLsWithContext(ctx, links, nil)
+	for i := range items {
+		if itemLinks[i] >= 0 {
+			set(&items[i], results[itemLinks[i]])
+		}
+	}
+}
+
+// decodeKey returns the key DecodeInto dedupes a link by: its article ID and
+// the edition it is resolved under, since some IDs only resolve under their
+// own edition. Links that are not article URLs are keyed by themselves.
+func (d *GoogleDecoder) decodeKey(link string) string {
+	c, parsedURL, err := classifyArticle(link)
+	if err != nil {
+		return link
+	}
+	return c.ID + "?" + resolveLocale(d.locale, parsedURL).query()
+}
@@ -0,0 +1,110 @@
+package gnewsdecoder_test
+
+import (
+	"context"
+	"testing"
+
+	gnews "github.com/alainmucyo/google-news-url-decoder"
+	"github.com/alainmucyo/google-news-url-decoder/gnewstest"
+)
+
+func TestDecodeInto(t *testing.T) {
+	srv := gnewstest.NewServer()
+	defer srv.Close()
+
+	id := opaqueID("AU_yqLinto", "")
+	srv.AddArticle(id, "https://example.com/into")
+	link := "https://news.google.com/rss/articles/" + id
+
+	decoder, err := gnews.NewGoogleDecoder(srv.Option())
+	if err != nil {
+		t.Fatalf("Failed to create GoogleDecoder: %v", err)
+	}
+
+	type article struct {
+		Title string
+		Link  string
+		URL   string
+		Error string
+	}
+	articles := []article{
+		{Title: "a", Link: link},
+		{Title: "b"},
+		{Title: "c", Link: link + "?oc=5"},
+		{Title: "d", Link: link},
+		{Title: "e", Link: "https://news.google.com/rss/articles/CBMiunknown"},
+	}
+
+	calls := 0
+	gnews.DecodeInto(context.Background(), decoder, articles,
+		func(a article) string { return a.Link },
+		func(a *article, r gnews.DecodeResult) {
+			calls++
+			a.URL, a.Error = r.DecodedURL, r.Message
+		})
+
+	if calls != 4 {
+		t.Errorf("set called %d times, want 4", calls)
+	}
+	for _, i := range []int{0, 2, 3} {
+		if articles[i].URL != "https://example.com/into" {
+			t.Errorf("articles[%d] = %+v, want the decoded URL", i, articles[i])
+		}
+	}
+	if articles[1].URL != "" || articles[1].Error != "" {
+		t.Errorf("articles[1] = %+v, want it skipped", articles[1])
+	}
+	if articles[4].URL != "" || articles[4].Error == "" {
+		t.Errorf("articles[4] = %+v, want a decode error", articles[4])
+	}
+}
+
+func TestDecodeInto_Dedupes(t *testing.T) {
+	srv := gnewstest.NewServer()
+	defer srv.Close()
+
+	id := opaqueID("AU_yqLdedupe", "")
+	srv.AddArticle(id, "https://example.com/dedupe")
+
+	decoder, err := gnews.NewGoogleDecoder(srv.Option())
+	if err != nil {
+		t.Fatalf("Failed to create GoogleDecoder: %v", err)
+	}
+
+	decode := func(links []string) []gnews.DecodeResult {
+		t.Helper()
+		var results []gnews.DecodeResult
+		gnews.DecodeInto(context.Background(), decoder, links,
+			func(s string) string { return s },
+			func(_ *string, r gnews.DecodeResult) { results = append(results, r) })
+		return results
+	}
+
+	decode([]string{"https://news.google.com/rss/articles/" + id})
+	once := srv.Requests()
+
+	// The same article ID, written three ways, is decoded once with the signed method
+	results := decode([]string{
+		"https://news.google.com/rss/articles/" + id,
+		"https://news.google.com/rss/articles/" + id + "?oc=5",
+		"https://news.google.com/articles/" + id,
+	})
+	for _, r := range results {
+		if r.DecodedURL != "https://example.com/dedupe" || r.Method != gnews.MethodSigned {
+			t.Errorf("DecodeInto() result = %+v, want a signed decode", r)
+		}
+	}
+	if got := srv.Requests() - once; got != once {
+		t.Errorf("decoding a duplicated article ID made %d requests, want %d", got, once)
+	}
+
+	// Each edition of an ID is decoded on its own
+	before := srv.Requests()
+	decode([]string{
+		"https://news.google.com/rss/articles/" + id,
+		"https://news.google.com/rss/articles/" + id + "?hl=fr&gl=FR&ceid=FR:fr",
+	})
+	if got := srv.Requests() - before; got != 2*once {
+		t.Errorf("decoding an ID under two editions made %d requests, want %d", got, 2*once)
+	}
+}
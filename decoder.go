@@ -3,14 +3,14 @@
 package gnewsdecoder
 
 import (
-	"encoding/base64"
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -20,9 +20,15 @@ const Version = "0.1.0"
 
 // DecodeResult represents the result of a URL decoding operation
 type DecodeResult struct {
-	Status     bool   `json:"status"`
-	DecodedURL string `json:"decoded_url,omitempty"`
-	Message    string `json:"message,omitempty"`
+	Status     bool         `json:"status"`
+	DecodedURL string       `json:"decoded_url,omitempty"`
+	AMPURL     string       `json:"amp_url,omitempty"`
+	Method     DecodeMethod `json:"method,omitempty"`
+	Message    string       `json:"message,omitempty"`
+	Proxy      string       `json:"proxy,omitempty"`
+
+	// Err holds the error of failures callers may want to match, such as ErrRequiresNetwork
+	Err error `json:"-"`
 }
 
 // DecodingParams contains the parameters needed for decoding
@@ -44,69 +50,43 @@ type BatchDecodeResult struct {
 // DecoderV1 decodes Google News URLs using base64 decoding (simple method).
 // This works for older/simpler Google News URL formats.
 func DecoderV1(sourceURL string) string {
-	parsedURL, err := url.Parse(sourceURL)
+	c, _, err := classifyArticle(sourceURL)
 	if err != nil {
 		return sourceURL
 	}
 
-	path := strings.Split(parsedURL.Path, "/")
-	if parsedURL.Host == "news.google.com" && len(path) > 1 && path[len(path)-2] == "articles" {
-		base64Str := path[len(path)-1]
-		decodedBytes, err := base64.URLEncoding.DecodeString(base64Str + "==")
-		if err != nil {
-			return sourceURL
-		}
-		decodedStr := string(decodedBytes)
-
-		// Remove prefix
-		prefix := string([]byte{0x08, 0x13, 0x22})
-		if strings.HasPrefix(decodedStr, prefix) {
-			decodedStr = decodedStr[len(prefix):]
-		}
-
-		// Remove suffix
-		suffix := string([]byte{0xD2, 0x01, 0x00})
-		if strings.HasSuffix(decodedStr, suffix) {
-			decodedStr = decodedStr[:len(decodedStr)-len(suffix)]
-		}
-
-		// Extract URL based on length byte
-		if len(decodedStr) > 0 {
-			length := int(decodedStr[0])
-			if length >= 0x80 && len(decodedStr) > length+1 {
-				decodedStr = decodedStr[2 : length+1]
-			} else if len(decodedStr) > length+1 {
-				decodedStr = decodedStr[1 : length+1]
-			}
-		}
-
-		return decodedStr
+	articleID, err := parseArticleID(c.ID)
+	if err != nil {
+		return sourceURL
 	}
 
-	return sourceURL
+	return articleID.URL
 }
 
 // fetchDecodedBatchExecute fetches the decoded URL using Google's batch execute API
-func fetchDecodedBatchExecute(id string, client *http.Client) (string, error) {
-	s := fmt.Sprintf(
-		`[[["Fbv4je","[\"garturlreq\",[[\"en-US\",\"US\",[\"FINANCE_TOP_INDICES\",\"WEB_TEST_1_0_0\"],`+
-			`null,null,1,1,\"US:en\",null,180,null,null,null,null,null,0,null,null,[1608992183,723341000]],`+
-			`\"en-US\",\"US\",1,[2,3,4,8],1,0,\"655000234\",0,0,null,0],\"%s\"]",null,"generic"]]]`,
-		id,
-	)
+func (d *GoogleDecoder) fetchDecodedBatchExecute(ctx context.Context, id string, locale Locale) (string, error) {
+	envelope, err := newGarturlEnvelope(garturlRequest{ID: id, Locale: locale}, "generic")
+	if err != nil {
+		return "", err
+	}
+	s, err := batchRequest{envelope}.encode()
+	if err != nil {
+		return "", err
+	}
 
 	reqBody := url.Values{}
 	reqBody.Set("f.req", s)
 
-	req, err := http.NewRequest("POST", "https://news.google.com/_/DotsSplashUi/data/batchexecute?rpcids=Fbv4je", strings.NewReader(reqBody.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", d.endpoints.batchExecuteURL(garturlRPCID), strings.NewReader(reqBody.Encode()))
 	if err != nil {
 		return "", err
 	}
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded;charset=utf-8")
-	req.Header.Set("Referer", "https://news.google.com/")
+	req.Header.Set("Referer", d.endpoints.referer())
+	req.Header.Set("User-Agent", defaultUserAgent)
 
-	resp, err := client.Do(req)
+	resp, err := d.client.Do(req)
 	if err != nil {
 		return "", err
 	}
@@ -121,277 +101,181 @@ func fetchDecodedBatchExecute(id string, client *http.Client) (string, error) {
 		return "", err
 	}
 
-	text := string(body)
-	header := `[\"garturlres\",\"`
-	footer := `\",`
-
-	if !strings.Contains(text, header) {
-		return "", fmt.Errorf("header not found in response")
+	urls, errs, err := garturlURLs(body)
+	if err != nil {
+		return "", err
 	}
-
-	parts := strings.SplitN(text, header, 2)
-	if len(parts) < 2 {
-		return "", fmt.Errorf("failed to parse response")
+	if err := errs["generic"]; err != nil {
+		return "", err
 	}
-
-	start := parts[1]
-	if !strings.Contains(start, footer) {
-		return "", fmt.Errorf("footer not found in response")
+	decodedURL, ok := urls["generic"]
+	if !ok {
+		return "", fmt.Errorf("decoded URL not found in response")
 	}
-
-	urlParts := strings.SplitN(start, footer, 2)
-	return urlParts[0], nil
+	return decodedURL, nil
 }
 
 // DecoderV2 decodes Google News URLs with batch execute fallback for AU_yqL prefixed URLs.
 // Returns the decoded URL or the original URL if decoding fails.
 func DecoderV2(sourceURL string) string {
-	parsedURL, err := url.Parse(sourceURL)
-	if err != nil {
+	result := defaultDecoder().DecodeV3(sourceURL)
+	if !result.Status {
 		return sourceURL
 	}
-
-	path := strings.Split(parsedURL.Path, "/")
-	if parsedURL.Host == "news.google.com" && len(path) > 1 && (path[len(path)-2] == "articles" || path[len(path)-2] == "read") {
-		base64Str := path[len(path)-1]
-		decodedBytes, err := base64.URLEncoding.DecodeString(base64Str + "==")
-		if err != nil {
-			return sourceURL
-		}
-		decodedStr := string(decodedBytes)
-
-		// Remove prefix
-		prefix := string([]byte{0x08, 0x13, 0x22})
-		if strings.HasPrefix(decodedStr, prefix) {
-			decodedStr = decodedStr[len(prefix):]
-		}
-
-		// Remove suffix
-		suffix := string([]byte{0xD2, 0x01, 0x00})
-		if strings.HasSuffix(decodedStr, suffix) {
-			decodedStr = decodedStr[:len(decodedStr)-len(suffix)]
-		}
-
-		// Extract URL based on length byte
-		if len(decodedStr) > 0 {
-			length := int(decodedStr[0])
-			if length >= 0x80 && len(decodedStr) > length+1 {
-				decodedStr = decodedStr[2 : length+1]
-			} else if len(decodedStr) > length+1 {
-				decodedStr = decodedStr[1 : length+1]
-			}
-		}
-
-		// If URL starts with AU_yqL, use batch execute
-		if strings.HasPrefix(decodedStr, "AU_yqL") {
-			client := &http.Client{Timeout: 30 * time.Second}
-			decoded, err := fetchDecodedBatchExecute(base64Str, client)
-			if err != nil {
-				return sourceURL
-			}
-			return decoded
-		}
-
-		return decodedStr
-	}
-
-	return sourceURL
+	return result.DecodedURL
 }
 
 // DecoderV3 decodes Google News URLs with proper error handling and status reporting.
 // Returns a DecodeResult with status and decoded URL or error message.
 func DecoderV3(sourceURL string) DecodeResult {
-	parsedURL, err := url.Parse(sourceURL)
+	return defaultDecoder().DecodeV3(sourceURL)
+}
+
+// decodeV3 decodes inline article IDs offline and resolves AU_yqL IDs through batch execute
+func (d *GoogleDecoder) decodeV3(ctx context.Context, sourceURL string) DecodeResult {
+	c, parsedURL, err := classifyArticle(sourceURL)
 	if err != nil {
-		return DecodeResult{Status: false, Message: fmt.Sprintf("failed to parse URL: %v", err)}
+		return DecodeResult{Status: false, Message: err.Error()}
 	}
 
-	path := strings.Split(parsedURL.Path, "/")
-	if parsedURL.Host == "news.google.com" && len(path) > 1 && (path[len(path)-2] == "articles" || path[len(path)-2] == "read") {
-		base64Str := path[len(path)-1]
-		decodedBytes, err := base64.URLEncoding.DecodeString(base64Str + "==")
-		if err != nil {
-			return DecodeResult{Status: false, Message: fmt.Sprintf("failed to decode base64: %v", err)}
-		}
-		decodedStr := string(decodedBytes)
-
-		// Remove prefix
-		prefix := string([]byte{0x08, 0x13, 0x22})
-		if strings.HasPrefix(decodedStr, prefix) {
-			decodedStr = decodedStr[len(prefix):]
-		}
-
-		// Remove suffix
-		suffix := string([]byte{0xD2, 0x01, 0x00})
-		if strings.HasSuffix(decodedStr, suffix) {
-			decodedStr = decodedStr[:len(decodedStr)-len(suffix)]
-		}
+	articleID, err := parseArticleID(c.ID)
+	if err != nil {
+		return DecodeResult{Status: false, Message: err.Error()}
+	}
 
-		// Extract URL based on length byte
-		if len(decodedStr) > 0 {
-			length := int(decodedStr[0])
-			if length >= 0x80 && len(decodedStr) > length+1 {
-				decodedStr = decodedStr[2 : length+1]
-			} else if len(decodedStr) > length+1 {
-				decodedStr = decodedStr[1 : length+1]
-			}
+	// If URL starts with AU_yqL, use batch execute
+	if articleID.Opaque() {
+		if d.offline {
+			return requiresNetwork()
 		}
-
-		// If URL starts with AU_yqL, use batch execute
-		if strings.HasPrefix(decodedStr, "AU_yqL") {
-			client := &http.Client{Timeout: 30 * time.Second}
-			decoded, err := fetchDecodedBatchExecute(base64Str, client)
-			if err != nil {
-				return DecodeResult{Status: false, Message: fmt.Sprintf("batch execute failed: %v", err)}
-			}
-			return DecodeResult{Status: true, DecodedURL: decoded}
+		decoded, err := d.fetchDecodedBatchExecute(ctx, c.ID, resolveLocale(d.locale, parsedURL))
+		if err != nil {
+			return DecodeResult{Status: false, Message: fmt.Sprintf("batch execute failed: %v", err)}
 		}
-
-		return DecodeResult{Status: true, DecodedURL: decodedStr}
+		return DecodeResult{Status: true, DecodedURL: decoded, Method: MethodBatchExecute}
 	}
 
-	return DecodeResult{Status: false, Message: "invalid Google News URL"}
+	return DecodeResult{Status: true, DecodedURL: articleID.URL, AMPURL: articleID.AMPURL, Method: MethodOffline}
 }
 
-// fetchDecodedBatchExecuteMultiple fetches multiple decoded URLs in a single batch request
-func fetchDecodedBatchExecuteMultiple(ids []string, client *http.Client) (BatchDecodeResult, error) {
-	var envelopes []string
+// fetchDecodedBatchExecuteMultiple resolves multiple article IDs in a single batch request.
+// locales holds the edition of each ID and ids must be distinct. Every ID is
+// sent with its index as tag and its result matched back through that tag.
+func (d *GoogleDecoder) fetchDecodedBatchExecuteMultiple(ctx context.Context, ids []string, locales []Locale) ([]DecodeResult, error) {
+	var request batchRequest
 	for i, id := range ids {
-		envelope := fmt.Sprintf(
-			`["Fbv4je","[\"garturlreq\",[[\"en-US\",\"US\",[\"FINANCE_TOP_INDICES\",\"WEB_TEST_1_0_0\"],`+
-				`null,null,1,1,\"US:en\",null,180,null,null,null,null,null,0,null,null,[1608992183,723341000]],`+
-				`\"en-US\",\"US\",1,[2,3,4,8],1,0,\"655000234\",0,0,null,0],\"%s\"]",null,"%d"]`,
-			id, i+1,
-		)
-		envelopes = append(envelopes, envelope)
+		envelope, err := newGarturlEnvelope(garturlRequest{ID: id, Locale: locales[i]}, strconv.Itoa(i+1))
+		if err != nil {
+			return nil, err
+		}
+		request = append(request, envelope)
 	}
 
-	s := fmt.Sprintf("[[%s]]", strings.Join(envelopes, ","))
+	s, err := request.encode()
+	if err != nil {
+		return nil, err
+	}
 
 	reqBody := url.Values{}
 	reqBody.Set("f.req", s)
 
-	req, err := http.NewRequest("POST", "https://news.google.com/_/DotsSplashUi/data/batchexecute?rpcids=Fbv4je", strings.NewReader(reqBody.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", d.endpoints.batchExecuteURL(garturlRPCID), strings.NewReader(reqBody.Encode()))
 	if err != nil {
-		return BatchDecodeResult{Status: false, Error: err.Error()}, err
+		return nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded;charset=utf-8")
-	req.Header.Set("Referer", "https://news.google.com/")
+	req.Header.Set("Referer", d.endpoints.referer())
+	req.Header.Set("User-Agent", defaultUserAgent)
 
-	resp, err := client.Do(req)
+	resp, err := d.client.Do(req)
 	if err != nil {
-		return BatchDecodeResult{Status: false, Error: err.Error()}, err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
-		errMsg := fmt.Sprintf("failed to fetch data from Google, status: %d", resp.StatusCode)
-		return BatchDecodeResult{Status: false, Error: errMsg}, errors.New(errMsg)
+		return nil, fmt.Errorf("failed to fetch data from Google, status: %d", resp.StatusCode)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return BatchDecodeResult{Status: false, Error: err.Error()}, err
+		return nil, err
 	}
 
-	text := string(body)
-	header := `[\"garturlres\",\"`
-	footer := `\",`
-
-	var urls []string
-	for strings.Contains(text, header) {
-		parts := strings.SplitN(text, header, 2)
-		if len(parts) < 2 {
-			break
-		}
-		start := parts[1]
-		if !strings.Contains(start, footer) {
-			break
+	urls, errs, err := garturlURLs(body)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]DecodeResult, len(ids))
+	for i := range ids {
+		tag := strconv.Itoa(i + 1)
+		switch decodedURL, ok := urls[tag]; {
+		case ok:
+			results[i] = DecodeResult{Status: true, DecodedURL: decodedURL, Method: MethodBatchExecute}
+		case errs[tag] != nil:
+			results[i] = DecodeResult{Status: false, Message: fmt.Sprintf("batch execute failed: %v", errs[tag])}
+		default:
+			results[i] = DecodeResult{Status: false, Message: "batch execute failed: no result for article ID in response"}
 		}
-		urlParts := strings.SplitN(start, footer, 2)
-		urls = append(urls, urlParts[0])
-		text = urlParts[1]
 	}
-
-	return BatchDecodeResult{Status: true, URLs: urls}, nil
+	return results, nil
 }
 
 // DecoderV4 decodes multiple Google News URLs in batch.
 // This is more efficient when decoding multiple URLs as it batches API requests.
 func DecoderV4(sourceURLs []string) []DecodeResult {
+	return defaultDecoder().DecodeBatch(sourceURLs)
+}
+
+// decodeBatch decodes inline article IDs offline and resolves all AU_yqL IDs in one batch execute call
+func (d *GoogleDecoder) decodeBatch(ctx context.Context, sourceURLs []string) []DecodeResult {
 	results := make([]DecodeResult, len(sourceURLs))
 	batchIDs := make([]string, 0)
-	idToIndex := make(map[string]int)
-
-	client := &http.Client{Timeout: 30 * time.Second}
+	batchLocales := make([]Locale, 0)
+	// idIndexes holds the positions of every URL carrying an ID, so duplicates are sent once
+	idIndexes := make(map[string][]int)
 
 	for i, sourceURL := range sourceURLs {
-		parsedURL, err := url.Parse(sourceURL)
+		c, parsedURL, err := classifyArticle(sourceURL)
 		if err != nil {
-			results[i] = DecodeResult{Status: false, Message: fmt.Sprintf("failed to parse URL: %v", err)}
+			results[i] = DecodeResult{Status: false, Message: err.Error()}
 			continue
 		}
 
-		path := strings.Split(parsedURL.Path, "/")
-		if parsedURL.Host != "news.google.com" || len(path) <= 1 || (path[len(path)-2] != "articles" && path[len(path)-2] != "read") {
-			results[i] = DecodeResult{Status: false, Message: "invalid Google News URL"}
-			continue
-		}
-
-		base64Str := path[len(path)-1]
-		decodedBytes, err := base64.URLEncoding.DecodeString(base64Str + "==")
+		base64Str := c.ID
+		articleID, err := parseArticleID(base64Str)
 		if err != nil {
-			results[i] = DecodeResult{Status: false, Message: fmt.Sprintf("failed to decode base64: %v", err)}
+			results[i] = DecodeResult{Status: false, Message: err.Error()}
 			continue
 		}
-		decodedStr := string(decodedBytes)
-
-		// Remove prefix
-		prefix := string([]byte{0x08, 0x13, 0x22})
-		if strings.HasPrefix(decodedStr, prefix) {
-			decodedStr = decodedStr[len(prefix):]
-		}
-
-		// Remove suffix
-		suffix := string([]byte{0xD2, 0x01, 0x00})
-		if strings.HasSuffix(decodedStr, suffix) {
-			decodedStr = decodedStr[:len(decodedStr)-len(suffix)]
-		}
-
-		// Extract URL based on length byte
-		if len(decodedStr) > 0 {
-			length := int(decodedStr[0])
-			if length >= 0x80 && len(decodedStr) > length+1 {
-				decodedStr = decodedStr[2 : length+1]
-			} else if len(decodedStr) > length+1 {
-				decodedStr = decodedStr[1 : length+1]
-			}
-		}
 
 		// If URL starts with AU_yqL, add to batch
-		if strings.HasPrefix(decodedStr, "AU_yqL") {
-			batchIDs = append(batchIDs, base64Str)
-			idToIndex[base64Str] = i
-		} else {
-			results[i] = DecodeResult{Status: true, DecodedURL: decodedStr}
+		switch {
+		case articleID.Opaque() && d.offline:
+			results[i] = requiresNetwork()
+		case articleID.Opaque():
+			if _, ok := idIndexes[base64Str]; !ok {
+				batchIDs = append(batchIDs, base64Str)
+				batchLocales = append(batchLocales, resolveLocale(d.locale, parsedURL))
+			}
+			idIndexes[base64Str] = append(idIndexes[base64Str], i)
+		default:
+			results[i] = DecodeResult{Status: true, DecodedURL: articleID.URL, AMPURL: articleID.AMPURL, Method: MethodOffline}
 		}
 	}
 
 	// Process batch IDs
 	if len(batchIDs) > 0 {
-		batchResult, err := fetchDecodedBatchExecuteMultiple(batchIDs, client)
-		if err != nil {
-			for _, id := range batchIDs {
-				idx := idToIndex[id]
-				results[idx] = DecodeResult{Status: false, Message: fmt.Sprintf("batch execute failed: %v", err)}
+		batchResults, err := d.fetchDecodedBatchExecuteMultiple(ctx, batchIDs, batchLocales)
+		for j, id := range batchIDs {
+			result := DecodeResult{Status: false, Message: fmt.Sprintf("batch execute failed: %v", err)}
+			if err == nil {
+				result = batchResults[j]
 			}
-		} else if batchResult.Status {
-			for j, decodedURL := range batchResult.URLs {
-				if j < len(batchIDs) {
-					idx := idToIndex[batchIDs[j]]
-					results[idx] = DecodeResult{Status: true, DecodedURL: decodedURL}
-				}
+			for _, idx := range idIndexes[id] {
+				results[idx] = result
 			}
 		}
 	}
@@ -399,10 +283,14 @@ func DecoderV4(sourceURLs []string) []DecodeResult {
 	return results
 }
 
+var (
+	sgRegex = regexp.MustCompile(`data-n-a-sg="([^"]+)"`)
+	tsRegex = regexp.MustCompile(`data-n-a-ts="([^"]+)"`)
+)
+
 // extractDataAttributes extracts signature and timestamp from Google News HTML page
 func extractDataAttributes(htmlContent string) (signature, timestamp string, err error) {
 	// Look for data-n-a-sg attribute
-	sgRegex := regexp.MustCompile(`data-n-a-sg="([^"]+)"`)
 	sgMatch := sgRegex.FindStringSubmatch(htmlContent)
 	if sgMatch == nil || len(sgMatch) < 2 {
 		return "", "", errors.New("signature not found in HTML")
@@ -410,7 +298,6 @@ func extractDataAttributes(htmlContent string) (signature, timestamp string, err
 	signature = sgMatch[1]
 
 	// Look for data-n-a-ts attribute
-	tsRegex := regexp.MustCompile(`data-n-a-ts="([^"]+)"`)
 	tsMatch := tsRegex.FindStringSubmatch(htmlContent)
 	if tsMatch == nil || len(tsMatch) < 2 {
 		return "", "", errors.New("timestamp not found in HTML")
@@ -420,17 +307,25 @@ func extractDataAttributes(htmlContent string) (signature, timestamp string, err
 	return signature, timestamp, nil
 }
 
-// getDecodingParams fetches signature and timestamp required for decoding from Google News
-func getDecodingParams(base64Str string, client *http.Client) DecodingParams {
+// getDecodingParams fetches signature and timestamp required for decoding from Google News.
+// acceptLanguage is only sent if set.
+func (d *GoogleDecoder) getDecodingParams(ctx context.Context, base64Str string, locale Locale, acceptLanguage string) DecodingParams {
+	if err := validateArticleID(base64Str); err != nil {
+		return DecodingParams{Status: false, Message: err.Error()}
+	}
+
 	// Try the articles URL first
-	articleURL := fmt.Sprintf("https://news.google.com/articles/%s", base64Str)
-	req, err := http.NewRequest("GET", articleURL, nil)
+	articleURL := d.endpoints.ArticleURL + base64Str + "?" + locale.query()
+	req, err := http.NewRequestWithContext(ctx, "GET", articleURL, nil)
 	if err != nil {
 		return DecodingParams{Status: false, Message: fmt.Sprintf("failed to create request: %v", err)}
 	}
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/129.0.0.0 Safari/537.36")
+	req.Header.Set("User-Agent", defaultUserAgent)
+	if acceptLanguage != "" {
+		req.Header.Set("Accept-Language", acceptLanguage)
+	}
 
-	resp, err := client.Do(req)
+	resp, err := d.client.Do(req)
 	if err == nil && resp.StatusCode == 200 {
 		body, _ := io.ReadAll(resp.Body)
 		resp.Body.Close()
@@ -449,14 +344,17 @@ func getDecodingParams(base64Str string, client *http.Client) DecodingParams {
 	}
 
 	// Fallback to RSS URL
-	rssURL := fmt.Sprintf("https://news.google.com/rss/articles/%s", base64Str)
-	req, err = http.NewRequest("GET", rssURL, nil)
+	rssURL := d.endpoints.RSSArticleURL + base64Str + "?" + locale.query()
+	req, err = http.NewRequestWithContext(ctx, "GET", rssURL, nil)
 	if err != nil {
 		return DecodingParams{Status: false, Message: fmt.Sprintf("failed to create RSS request: %v", err)}
 	}
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/129.0.0.0 Safari/537.36")
+	req.Header.Set("User-Agent", defaultUserAgent)
+	if acceptLanguage != "" {
+		req.Header.Set("Accept-Language", acceptLanguage)
+	}
 
-	resp, err = client.Do(req)
+	resp, err = d.client.Do(req)
 	if err != nil {
 		return DecodingParams{Status: false, Message: fmt.Sprintf("request error: %v", err)}
 	}
@@ -484,32 +382,41 @@ func getDecodingParams(base64Str string, client *http.Client) DecodingParams {
 	}
 }
 
-// decodeURLWithParams decodes the Google News URL using signature and timestamp
-func decodeURLWithParams(signature, timestamp, base64Str string, client *http.Client) DecodeResult {
-	apiURL := "https://news.google.com/_/DotsSplashUi/data/batchexecute"
+// decodeURLWithParams decodes the Google News URL using signature and timestamp.
+// acceptLanguage is only sent if set.
+func (d *GoogleDecoder) decodeURLWithParams(ctx context.Context, signature, timestamp, base64Str string, locale Locale, acceptLanguage string) DecodeResult {
+	apiURL := d.endpoints.BatchExecuteURL
 
-	payload := []interface{}{
-		"Fbv4je",
-		fmt.Sprintf(`["garturlreq",[["X","X",["X","X"],null,null,1,1,"US:en",null,1,null,null,null,null,null,0,1],"X","X",1,[1,1,1],1,1,null,0,0,null,0],"%s",%s,"%s"]`, base64Str, timestamp, signature),
+	ts, err := parseTimestamp(timestamp)
+	if err != nil {
+		return DecodeResult{Status: false, Message: err.Error()}
+	}
+
+	envelope, err := newGarturlEnvelope(garturlRequest{ID: base64Str, Locale: locale, Timestamp: ts, Signature: signature}, "")
+	if err != nil {
+		return DecodeResult{Status: false, Message: err.Error()}
 	}
 
-	payloadJSON, err := json.Marshal([][]interface{}{{payload}})
+	payload, err := batchRequest{envelope}.encode()
 	if err != nil {
-		return DecodeResult{Status: false, Message: fmt.Sprintf("failed to marshal payload: %v", err)}
+		return DecodeResult{Status: false, Message: err.Error()}
 	}
 
 	formData := url.Values{}
-	formData.Set("f.req", string(payloadJSON))
+	formData.Set("f.req", payload)
 
-	req, err := http.NewRequest("POST", apiURL, strings.NewReader(formData.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, strings.NewReader(formData.Encode()))
 	if err != nil {
 		return DecodeResult{Status: false, Message: fmt.Sprintf("failed to create request: %v", err)}
 	}
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded;charset=UTF-8")
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/129.0.0.0 Safari/537.36")
+	req.Header.Set("User-Agent", defaultUserAgent)
+	if acceptLanguage != "" {
+		req.Header.Set("Accept-Language", acceptLanguage)
+	}
 
-	resp, err := client.Do(req)
+	resp, err := d.client.Do(req)
 	if err != nil {
 		return DecodeResult{Status: false, Message: fmt.Sprintf("request error: %v", err)}
 	}
@@ -520,84 +427,43 @@ func decodeURLWithParams(signature, timestamp, base64Str string, client *http.Cl
 		return DecodeResult{Status: false, Message: fmt.Sprintf("failed to read response: %v", err)}
 	}
 
-	// Parse the response - split by double newline and parse JSON
-	parts := strings.SplitN(string(body), "\n\n", 2)
-	if len(parts) < 2 {
-		return DecodeResult{Status: false, Message: "invalid response format"}
-	}
-
-	var parsed []interface{}
-	if err := json.Unmarshal([]byte(parts[1]), &parsed); err != nil {
-		return DecodeResult{Status: false, Message: fmt.Sprintf("failed to parse response JSON: %v", err)}
-	}
-
-	// Navigate the nested structure to get the decoded URL
-	if len(parsed) < 1 {
-		return DecodeResult{Status: false, Message: "empty response"}
-	}
-
-	// The structure is: [[["...",null,"[\"...\",\"decoded_url\"]"]]]
-	outerArr, ok := parsed[0].([]interface{})
-	if !ok || len(outerArr) < 3 {
-		return DecodeResult{Status: false, Message: "unexpected response structure"}
-	}
-
-	innerJSON, ok := outerArr[2].(string)
-	if !ok {
-		return DecodeResult{Status: false, Message: "failed to extract inner JSON"}
-	}
-
-	var innerData []interface{}
-	if err := json.Unmarshal([]byte(innerJSON), &innerData); err != nil {
-		return DecodeResult{Status: false, Message: fmt.Sprintf("failed to parse inner JSON: %v", err)}
-	}
-
-	if len(innerData) < 2 {
-		return DecodeResult{Status: false, Message: "decoded URL not found in response"}
-	}
-
-	decodedURL, ok := innerData[1].(string)
-	if !ok {
-		return DecodeResult{Status: false, Message: "decoded URL is not a string"}
+	decodedURL, err := parseDecodedURLResponse(body)
+	if err != nil {
+		return DecodeResult{Status: false, Message: err.Error()}
 	}
 
-	return DecodeResult{Status: true, DecodedURL: decodedURL}
+	return DecodeResult{Status: true, DecodedURL: decodedURL, Method: MethodSigned}
 }
 
 // NewDecoderV1 decodes Google News URLs using the new method with signature and timestamp.
 // This is the recommended decoder for most use cases.
 func NewDecoderV1(sourceURL string, interval *time.Duration) DecodeResult {
-	client := &http.Client{Timeout: 30 * time.Second}
-	return newDecoderV1WithClient(sourceURL, interval, client)
+	return defaultDecoder().Decode(sourceURL, interval)
 }
 
-func newDecoderV1WithClient(sourceURL string, interval *time.Duration, client *http.Client) DecodeResult {
-	// Extract base64 string
-	parsedURL, err := url.Parse(sourceURL)
-	if err != nil {
-		return DecodeResult{Status: false, Message: fmt.Sprintf("failed to parse URL: %v", err)}
-	}
-
-	path := strings.Split(parsedURL.Path, "/")
-	if parsedURL.Host != "news.google.com" || len(path) <= 1 {
-		return DecodeResult{Status: false, Message: "invalid Google News URL format"}
+// decode decodes sourceURL using the signature and timestamp method
+func (d *GoogleDecoder) decode(ctx context.Context, sourceURL string, interval *time.Duration) DecodeResult {
+	// The signed method always needs the network, so offline decoders read the ID instead
+	if d.offline {
+		return d.decodeV3(ctx, sourceURL)
 	}
 
-	pathType := path[len(path)-2]
-	if pathType != "articles" && pathType != "read" {
-		return DecodeResult{Status: false, Message: "invalid Google News URL format"}
+	// Extract base64 string
+	c, parsedURL, err := classifyArticle(sourceURL)
+	if err != nil {
+		return DecodeResult{Status: false, Message: err.Error()}
 	}
-
-	base64Str := path[len(path)-1]
+	base64Str := c.ID
 
 	// Get decoding parameters
-	params := getDecodingParams(base64Str, client)
+	edition, acceptLanguage := d.requestLocale(parsedURL)
+	params := d.getDecodingParams(ctx, base64Str, edition, acceptLanguage)
 	if !params.Status {
 		return DecodeResult{Status: false, Message: params.Message}
 	}
 
 	// Decode URL
-	result := decodeURLWithParams(params.Signature, params.Timestamp, params.Base64Str, client)
+	result := d.decodeURLWithParams(ctx, params.Signature, params.Timestamp, params.Base64Str, edition, acceptLanguage)
 
 	// Apply interval if specified
 	if interval != nil {
@@ -0,0 +1,90 @@
+package gnewsdecoder
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Endpoints holds the Google News URLs the decoder talks to.
+// ArticleURL and RSSArticleURL are prefixes the article ID is appended to,
+// FeedURL is the root of the RSS feeds fetched by FetchFeed.
+type Endpoints struct {
+	ArticleURL      string
+	RSSArticleURL   string
+	BatchExecuteURL string
+	FeedURL         string
+}
+
+// DefaultEndpoints are the production Google News endpoints
+var DefaultEndpoints = Endpoints{
+	ArticleURL:      "https://news.google.com/articles/",
+	RSSArticleURL:   "https://news.google.com/rss/articles/",
+	BatchExecuteURL: "https://news.google.com/_/DotsSplashUi/data/batchexecute",
+	FeedURL:         "https://news.google.com/rss",
+}
+
+// WithEndpoints overrides the Google News endpoints, e.g. to target a local
+// stand-in server. Empty fields keep their default.
+func WithEndpoints(endpoints Endpoints) DecoderOption {
+	return func(d *GoogleDecoder) {
+		d.endpoints = endpoints
+	}
+}
+
+// WithBaseURL points every endpoint at the given scheme and host,
+// e.g. the URL of an httptest.Server.
+func WithBaseURL(baseURL string) DecoderOption {
+	base := strings.TrimRight(baseURL, "/")
+	return WithEndpoints(Endpoints{
+		ArticleURL:      base + "/articles/",
+		RSSArticleURL:   base + "/rss/articles/",
+		BatchExecuteURL: base + "/_/DotsSplashUi/data/batchexecute",
+		FeedURL:         base + "/rss",
+	})
+}
+
+// merge returns e with the non-empty fields of override applied, after validating them
+func (e Endpoints) merge(override Endpoints) (Endpoints, error) {
+	for _, field := range []struct {
+		name  string
+		value string
+		dst   *string
+	}{
+		{"article", override.ArticleURL, &e.ArticleURL},
+		{"RSS article", override.RSSArticleURL, &e.RSSArticleURL},
+		{"batchexecute", override.BatchExecuteURL, &e.BatchExecuteURL},
+		{"feed", override.FeedURL, &e.FeedURL},
+	} {
+		if field.value == "" {
+			continue
+		}
+		parsedURL, err := url.Parse(field.value)
+		if err != nil {
+			return Endpoints{}, fmt.Errorf("invalid %s endpoint: %v", field.name, err)
+		}
+		if (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+			return Endpoints{}, fmt.Errorf("invalid %s endpoint %q: must be an absolute http(s) URL", field.name, field.value)
+		}
+		*field.dst = field.value
+	}
+	return e, nil
+}
+
+// batchExecuteURL returns the batchexecute URL for the given RPC ID
+func (e Endpoints) batchExecuteURL(rpcID string) string {
+	sep := "?"
+	if strings.Contains(e.BatchExecuteURL, "?") {
+		sep = "&"
+	}
+	return e.BatchExecuteURL + sep + "rpcids=" + url.QueryEscape(rpcID)
+}
+
+// referer returns the origin of the batchexecute endpoint, sent as Referer
+func (e Endpoints) referer() string {
+	parsedURL, err := url.Parse(e.BatchExecuteURL)
+	if err != nil {
+		return "https://news.google.com/"
+	}
+	return parsedURL.Scheme + "://" + parsedURL.Host + "/"
+}
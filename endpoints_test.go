@@ -0,0 +1,72 @@
+package gnewsdecoder_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	gnews "github.com/alainmucyo/google-news-url-decoder"
+)
+
+// opaqueID builds an article ID wrapping AU_yqL tokens in the URL and AMP URL fields,
+// which needs batch execute to resolve
+func opaqueID(token, ampToken string) string {
+	id, _ := gnews.EncodeArticleID(token, gnews.EncodeOptions{AMPURL: ampToken})
+	return id
+}
+
+func newStandInServer(t *testing.T, decoded string) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/articles/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><div jscontroller="x" data-n-a-sg="sig123" data-n-a-ts="1700000000"></div></html>`)
+	})
+	mux.HandleFunc("/_/DotsSplashUi/data/batchexecute", func(w http.ResponseWriter, r *http.Request) {
+		// Echo the tag of the call like Google does
+		tag := "generic"
+		var envelopes [][][]any
+		if json.Unmarshal([]byte(r.FormValue("f.req")), &envelopes) == nil && len(envelopes) == 1 && len(envelopes[0]) == 1 && len(envelopes[0][0]) == 4 {
+			tag, _ = envelopes[0][0][3].(string)
+		}
+		fmt.Fprintf(w, ")]}'\n\n[[\"wrb.fr\",\"Fbv4je\",\"[\\\"garturlres\\\",\\\"%s\\\",1]\",null,null,null,\"%s\"]]", decoded, tag)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestEndpoints_WithBaseURL(t *testing.T) {
+	srv := newStandInServer(t, "https://example.com/story")
+
+	decoder, err := gnews.NewGoogleDecoder(gnews.WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatalf("Failed to create GoogleDecoder: %v", err)
+	}
+
+	sourceURL := "https://news.google.com/rss/articles/" + opaqueID("AU_yqLabc", "AU_yqLamp")
+
+	if result := decoder.Decode(sourceURL, nil); !result.Status || result.DecodedURL != "https://example.com/story" || result.Method != gnews.MethodSigned {
+		t.Errorf("Decode() = %+v", result)
+	}
+	if result := decoder.DecodeV3(sourceURL); !result.Status || result.DecodedURL != "https://example.com/story" || result.Method != gnews.MethodBatchExecute {
+		t.Errorf("DecodeV3() = %+v", result)
+	}
+
+	results := decoder.DecodeBatch([]string{sourceURL, "https://example.com/not-google-news"})
+	if !results[0].Status || results[0].DecodedURL != "https://example.com/story" {
+		t.Errorf("DecodeBatch()[0] = %+v", results[0])
+	}
+	if results[1].Status {
+		t.Errorf("DecodeBatch()[1] = %+v, want failure", results[1])
+	}
+}
+
+func TestEndpoints_Invalid(t *testing.T) {
+	_, err := gnews.NewGoogleDecoder(gnews.WithEndpoints(gnews.Endpoints{BatchExecuteURL: "/relative"}))
+	if err == nil || !strings.Contains(err.Error(), "batchexecute") {
+		t.Errorf("Expected batchexecute endpoint error, got %v", err)
+	}
+}
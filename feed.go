@@ -0,0 +1,328 @@
+package gnewsdecoder
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FeedNamespace is the XML namespace of the elements RewriteFeed adds, bound to the gnews prefix
+const FeedNamespace = "https://github.com/alainmucyo/google-news-url-decoder"
+
+// Feed is a parsed Google News RSS 2.0 feed
+type Feed struct {
+	Title       string     `json:"title"`
+	Link        string     `json:"link,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Language    string     `json:"language,omitempty"`
+	Items       []FeedItem `json:"items"`
+}
+
+// FeedItem is one item of a Google News RSS feed
+type FeedItem struct {
+	Title string `json:"title"`
+	// Link is the Google News URL of the article
+	Link       string    `json:"link"`
+	DecodedURL string    `json:"decoded_url,omitempty"`
+	GUID       string    `json:"guid,omitempty"`
+	Published  time.Time `json:"published"`
+	Source     string    `json:"source,omitempty"`
+	SourceURL  string    `json:"source_url,omitempty"`
+	// Description is the HTML description, parsed into Cluster when it lists related coverage
+	Description string   `json:"description,omitempty"`
+	Cluster     *Cluster `json:"cluster,omitempty"`
+	// Categories are the item's RSS categories, e.g. the tags of the feed it came from
+	Categories []string `json:"categories,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// rssDocument is the XML layout of an RSS 2.0 feed
+type rssDocument struct {
+	XMLName xml.Name `xml:"rss"`
+	Channel struct {
+		Title       string `xml:"title"`
+		Link        string `xml:"link"`
+		Description string `xml:"description"`
+		Language    string `xml:"language"`
+		Items       []struct {
+			Title       string   `xml:"title"`
+			Link        string   `xml:"link"`
+			GUID        string   `xml:"guid"`
+			PubDate     string   `xml:"pubDate"`
+			Description string   `xml:"description"`
+			Categories  []string `xml:"category"`
+			Source      struct {
+				Name string `xml:",chardata"`
+				URL  string `xml:"url,attr"`
+			} `xml:"source"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+// ParseFeed parses a Google News RSS 2.0 feed without decoding its links
+func ParseFeed(r io.Reader) (*Feed, error) {
+	var doc rssDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse feed: %v", err)
+	}
+
+	feed := &Feed{
+		Title:       strings.TrimSpace(doc.Channel.Title),
+		Link:        strings.TrimSpace(doc.Channel.Link),
+		Description: strings.TrimSpace(doc.Channel.Description),
+		Language:    strings.TrimSpace(doc.Channel.Language),
+		Items:       make([]FeedItem, 0, len(doc.Channel.Items)),
+	}
+	for _, it := range doc.Channel.Items {
+		item := FeedItem{
+			Title:       strings.TrimSpace(it.Title),
+			Link:        strings.TrimSpace(it.Link),
+			GUID:        strings.TrimSpace(it.GUID),
+			Source:      strings.TrimSpace(it.Source.Name),
+			SourceURL:   strings.TrimSpace(it.Source.URL),
+			Description: it.Description,
+		}
+		for _, category := range it.Categories {
+			if category = strings.TrimSpace(category); category != "" {
+				item.Categories = append(item.Categories, category)
+			}
+		}
+		if published, err := time.Parse(time.RFC1123, strings.TrimSpace(it.PubDate)); err == nil {
+			item.Published = published
+		}
+		if cluster, err := ParseDescription(it.Description); err == nil {
+			item.Cluster = &cluster
+		}
+		feed.Items = append(feed.Items, item)
+	}
+	return feed, nil
+}
+
+// FetchFeed downloads the feed described by q and decodes its links with
+// DecodeFeedItems. The request goes out like any other of the decoder, see Do.
+// Queries without an edition get the decoder's locale. Items that fail to
+// decode are reported in FeedItem.Error.
+func (d *GoogleDecoder) FetchFeed(ctx context.Context, q *FeedQuery) (*Feed, error) {
+	feedURL, err := q.build(d.endpoints.FeedURL, d.locale)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feedURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	resp, err := d.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("feed request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("feed request failed with status: %d", resp.StatusCode)
+	}
+
+	feed, err := ParseFeed(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	DecodeFeedItems(ctx, d, feed.Items)
+	return feed, nil
+}
+
+// DecodeFeedItems decodes the link of every item in place, with
+// DecodeInto. Failures are reported in FeedItem.Error.
+func DecodeFeedItems(ctx context.Context, decoder *GoogleDecoder, items []FeedItem) {
+	DecodeInto(ctx, decoder, items,
+		func(item FeedItem) string { return item.Link },
+		func(item *FeedItem, result DecodeResult) {
+			if result.Status {
+				item.DecodedURL, item.Error = result.DecodedURL, ""
+			} else {
+				item.DecodedURL, item.Error = "", result.Message
+			}
+		})
+}
+
+// RewriteFeed reads a Google News RSS 2.0 feed, decodes the link of every item
+// and writes the feed back with <link> and <guid> replaced by the publisher URL.
+// The original values are kept in <gnews:originalLink> and <gnews:originalGuid>.
+// Items whose links fail to decode are left unchanged. Everything else is copied
+// byte for byte.
+func RewriteFeed(r io.Reader, w io.Writer, decoder *GoogleDecoder) error {
+	_, err := RewriteFeedWithContext(context.Background(), r, w, decoder)
+	return err
+}
+
+// RewriteFeedWithContext is like RewriteFeed and returns the decode result of
+// every item link, in feed order. Links are decoded with a ConcurrentDecoder.
+func RewriteFeedWithContext(ctx context.Context, r io.Reader, w io.Writer, decoder *GoogleDecoder) ([]DecodeResult, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feed: %v", err)
+	}
+
+	layout, err := scanRSS(data)
+	if err != nil {
+		return nil, err
+	}
+
+	links := make([]string, len(layout.items))
+	for i, item := range layout.items {
+		links[i] = item.link.text
+	}
+	results := NewConcurrentDecoder(decoder, 0).DecodeURLsWithContext(ctx, links, nil)
+
+	var edits []textEdit
+	if !layout.hasNamespace {
+		edits = append(edits, textEdit{
+			start: layout.rssTagEnd - 1,
+			end:   layout.rssTagEnd - 1,
+			text:  fmt.Sprintf(` xmlns:gnews="%s"`, FeedNamespace),
+		})
+	}
+	for i, item := range layout.items {
+		if !results[i].Status {
+			continue
+		}
+		decoded := escapeXML(results[i].DecodedURL)
+
+		original := "<gnews:originalLink>" + escapeXML(item.link.text) + "</gnews:originalLink>"
+		edits = append(edits, textEdit{start: item.link.start, end: item.link.end, text: "<link>" + decoded + "</link>"})
+		if item.guid != nil {
+			original += "<gnews:originalGuid>" + escapeXML(item.guid.text) + "</gnews:originalGuid>"
+			edits = append(edits, textEdit{start: item.guid.start, end: item.guid.end, text: `<guid isPermaLink="true">` + decoded + "</guid>"})
+		}
+		edits = append(edits, textEdit{start: item.end, end: item.end, text: original})
+	}
+
+	if _, err := w.Write(applyEdits(data, edits)); err != nil {
+		return results, fmt.Errorf("failed to write feed: %v", err)
+	}
+	return results, nil
+}
+
+// rssLayout records the byte offsets of the parts of an RSS feed RewriteFeed changes
+type rssLayout struct {
+	rssTagEnd    int64
+	hasNamespace bool
+	items        []rssItemLayout
+}
+
+type rssItemLayout struct {
+	link *rssElement
+	guid *rssElement
+	// end is the offset of the </item> end tag
+	end int64
+}
+
+// rssElement is a simple text element with its offsets, end tag included
+type rssElement struct {
+	start, end int64
+	text       string
+}
+
+// scanRSS locates the <rss> start tag and the link and guid of every item
+func scanRSS(data []byte) (rssLayout, error) {
+	var layout rssLayout
+
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	depth, itemDepth := 0, 0
+	var item *rssItemLayout
+	var current *rssElement
+	var text strings.Builder
+
+	for {
+		start := dec.InputOffset()
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return rssLayout{}, fmt.Errorf("failed to parse feed: %v", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			switch {
+			case depth == 1:
+				if t.Name.Local != "rss" || t.Name.Space != "" {
+					return rssLayout{}, errors.New("not an RSS 2.0 feed")
+				}
+				layout.rssTagEnd = dec.InputOffset()
+				for _, attr := range t.Attr {
+					if attr.Name.Space == "xmlns" && attr.Name.Local == "gnews" {
+						layout.hasNamespace = true
+					}
+				}
+			case t.Name.Space == "" && t.Name.Local == "item" && item == nil:
+				item, itemDepth = &rssItemLayout{}, depth
+			case item != nil && depth == itemDepth+1 && t.Name.Space == "" && (t.Name.Local == "link" || t.Name.Local == "guid"):
+				current = &rssElement{start: start}
+				text.Reset()
+			}
+		case xml.CharData:
+			if current != nil {
+				text.Write(t)
+			}
+		case xml.EndElement:
+			switch {
+			case current != nil && depth == itemDepth+1:
+				current.end = dec.InputOffset()
+				current.text = strings.TrimSpace(text.String())
+				if t.Name.Local == "link" && item.link == nil {
+					item.link = current
+				} else if t.Name.Local == "guid" && item.guid == nil {
+					item.guid = current
+				}
+				current = nil
+			case item != nil && depth == itemDepth:
+				item.end = start
+				if item.link != nil {
+					layout.items = append(layout.items, *item)
+				}
+				item = nil
+			}
+			depth--
+		}
+	}
+
+	if layout.rssTagEnd == 0 {
+		return rssLayout{}, errors.New("not an RSS 2.0 feed")
+	}
+	return layout, nil
+}
+
+// textEdit replaces data[start:end] with text
+type textEdit struct {
+	start, end int64
+	text       string
+}
+
+func applyEdits(data []byte, edits []textEdit) []byte {
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+
+	var out bytes.Buffer
+	var pos int64
+	for _, edit := range edits {
+		out.Write(data[pos:edit.start])
+		out.WriteString(edit.text)
+		pos = edit.end
+	}
+	out.Write(data[pos:])
+	return out.Bytes()
+}
+
+func escapeXML(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
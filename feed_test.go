@@ -0,0 +1,109 @@
+package gnewsdecoder_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"testing"
+
+	gnews "github.com/alainmucyo/google-news-url-decoder"
+	"github.com/alainmucyo/google-news-url-decoder/gnewstest"
+)
+
+// testFeed returns a Google News RSS feed with one item per article ID
+func testFeed(ids ...string) string {
+	var items strings.Builder
+	for i, id := range ids {
+		fmt.Fprintf(&items, `
+    <item>
+      <title>Story %[1]d - Example</title>
+      <link>https://news.google.com/rss/articles/%[2]s?oc=5</link>
+      <guid isPermaLink="false">%[2]s</guid>
+      <pubDate>Mon, 10 Feb 2025 10:00:00 GMT</pubDate>
+      <description>&lt;a href="https://news.google.com/rss/articles/%[2]s?oc=5"&gt;Story %[1]d&lt;/a&gt;</description>
+      <source url="https://example.com">Example</source>
+    </item>`, i+1, id)
+	}
+	return `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<rss version="2.0" xmlns:media="http://search.yahoo.com/mrss/">
+  <channel>
+    <generator>NFE/5.0</generator>
+    <title>"golang" - Google News</title>
+    <link>https://news.google.com/search?q=golang&amp;hl=en-US&amp;gl=US&amp;ceid=US:en</link>
+    <language>en-US</language>` + items.String() + `
+  </channel>
+</rss>`
+}
+
+func TestRewriteFeed(t *testing.T) {
+	srv := gnewstest.NewServer()
+	defer srv.Close()
+
+	first, second := opaqueID("AU_yqLfeed1", ""), opaqueID("AU_yqLfeed2", "")
+	srv.AddArticle(first, "https://example.com/first?a=1&b=2")
+	srv.AddArticle(second, "https://example.com/second")
+
+	decoder, err := gnews.NewGoogleDecoder(srv.Option())
+	if err != nil {
+		t.Fatalf("Failed to create GoogleDecoder: %v", err)
+	}
+
+	input := testFeed(first, "CBMiunknown", second)
+	var out bytes.Buffer
+	results, err := gnews.RewriteFeedWithContext(context.Background(), strings.NewReader(input), &out, decoder)
+	if err != nil {
+		t.Fatalf("RewriteFeedWithContext() error = %v", err)
+	}
+	if len(results) != 3 || !results[0].Status || results[1].Status || !results[2].Status {
+		t.Fatalf("results = %+v", results)
+	}
+
+	var feed struct {
+		Items []struct {
+			Link         string `xml:"link"`
+			GUID         string `xml:"guid"`
+			OriginalLink string `xml:"https://github.com/alainmucyo/google-news-url-decoder originalLink"`
+			OriginalGUID string `xml:"https://github.com/alainmucyo/google-news-url-decoder originalGuid"`
+		} `xml:"channel>item"`
+	}
+	if err := xml.Unmarshal(out.Bytes(), &feed); err != nil {
+		t.Fatalf("Rewritten feed is not valid XML: %v\n%s", err, out.String())
+	}
+	if len(feed.Items) != 3 {
+		t.Fatalf("got %d items, want 3", len(feed.Items))
+	}
+
+	item := feed.Items[0]
+	if item.Link != "https://example.com/first?a=1&b=2" || item.GUID != item.Link {
+		t.Errorf("items[0] link, guid = %q, %q", item.Link, item.GUID)
+	}
+	if item.OriginalLink != "https://news.google.com/rss/articles/"+first+"?oc=5" || item.OriginalGUID != first {
+		t.Errorf("items[0] originals = %q, %q", item.OriginalLink, item.OriginalGUID)
+	}
+
+	// Undecodable items are left unchanged
+	if item := feed.Items[1]; item.Link != "https://news.google.com/rss/articles/CBMiunknown?oc=5" || item.OriginalLink != "" {
+		t.Errorf("items[1] = %+v", item)
+	}
+	if feed.Items[2].Link != "https://example.com/second" {
+		t.Errorf("items[2] link = %q", feed.Items[2].Link)
+	}
+
+	// Everything else is copied verbatim
+	for _, want := range []string{`xmlns:media="http://search.yahoo.com/mrss/"`, `<generator>NFE/5.0</generator>`, `<source url="https://example.com">Example</source>`} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Rewritten feed lost %s", want)
+		}
+	}
+}
+
+func TestRewriteFeed_NotRSS(t *testing.T) {
+	decoder, _ := gnews.NewGoogleDecoder(gnews.WithOfflineOnly())
+	for _, input := range []string{`<feed xmlns="http://www.w3.org/2005/Atom"></feed>`, `<rss><channel>`, ``} {
+		if err := gnews.RewriteFeed(strings.NewReader(input), &bytes.Buffer{}, decoder); err == nil {
+			t.Errorf("RewriteFeed(%q) succeeded, want error", input)
+		}
+	}
+}
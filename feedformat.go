@@ -0,0 +1,299 @@
+package gnewsdecoder
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// FeedFormat is an output format of WriteFeed
+type FeedFormat string
+
+// Output formats of WriteFeed
+const (
+	FormatRSS      FeedFormat = "rss"      // RSS 2.0
+	FormatAtom     FeedFormat = "atom"     // Atom 1.0
+	FormatJSONFeed FeedFormat = "jsonfeed" // JSON Feed 1.1
+)
+
+// ParseFeedFormat parses "rss", "atom" or "jsonfeed"
+func ParseFeedFormat(s string) (FeedFormat, error) {
+	switch f := FeedFormat(strings.ToLower(strings.TrimSpace(s))); f {
+	case FormatRSS, FormatAtom, FormatJSONFeed:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown feed format %q, want rss, atom or jsonfeed", s)
+	}
+}
+
+// WriteFeed writes feed in the given format. Every entry links to the
+// publisher URL when its link was decoded, with the Google News URL as an
+// alternate link, and to the Google News URL otherwise. Source names,
+// published dates and categories are kept.
+func WriteFeed(w io.Writer, feed *Feed, format FeedFormat) error {
+	var err error
+	switch format {
+	case FormatRSS:
+		err = writeRSS(w, feed)
+	case FormatAtom:
+		err = writeAtom(w, feed)
+	case FormatJSONFeed:
+		err = writeJSONFeed(w, feed)
+	default:
+		return fmt.Errorf("unknown feed format %q, want rss, atom or jsonfeed", format)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write feed: %v", err)
+	}
+	return nil
+}
+
+// itemURL returns the publisher URL of an item, else its Google News link
+func itemURL(item FeedItem) string {
+	if item.DecodedURL != "" {
+		return item.DecodedURL
+	}
+	return item.Link
+}
+
+// feedUpdated returns the most recent publication date of the feed, or now
+func feedUpdated(feed *Feed) time.Time {
+	var updated time.Time
+	for _, item := range feed.Items {
+		if item.Published.After(updated) {
+			updated = item.Published
+		}
+	}
+	if updated.IsZero() {
+		updated = time.Now()
+	}
+	return updated.UTC()
+}
+
+// rssDateFormat is RFC 1123 with the GMT zone Google News feeds use
+const rssDateFormat = "Mon, 02 Jan 2006 15:04:05 GMT"
+
+type rssOutput struct {
+	XMLName   xml.Name `xml:"rss"`
+	Version   string   `xml:"version,attr"`
+	Namespace string   `xml:"xmlns:gnews,attr"`
+	Channel   struct {
+		Title       string          `xml:"title"`
+		Link        string          `xml:"link,omitempty"`
+		Description string          `xml:"description"`
+		Language    string          `xml:"language,omitempty"`
+		Items       []rssOutputItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type rssOutputItem struct {
+	Title string `xml:"title"`
+	Link  string `xml:"link"`
+	GUID  struct {
+		IsPermaLink bool   `xml:"isPermaLink,attr"`
+		Value       string `xml:",chardata"`
+	} `xml:"guid"`
+	Description  string     `xml:"description,omitempty"`
+	PubDate      string     `xml:"pubDate,omitempty"`
+	Categories   []string   `xml:"category"`
+	Source       *rssSource `xml:"source"`
+	OriginalLink string     `xml:"gnews:originalLink,omitempty"`
+}
+
+type rssSource struct {
+	URL  string `xml:"url,attr"`
+	Name string `xml:",chardata"`
+}
+
+func writeRSS(w io.Writer, feed *Feed) error {
+	out := rssOutput{Version: "2.0", Namespace: FeedNamespace}
+	out.Channel.Title = feed.Title
+	out.Channel.Link = feed.Link
+	out.Channel.Description = feed.Description
+	if out.Channel.Description == "" {
+		out.Channel.Description = feed.Title
+	}
+	out.Channel.Language = feed.Language
+
+	for _, item := range feed.Items {
+		o := rssOutputItem{Title: item.Title, Link: itemURL(item), Description: item.Description, Categories: item.Categories}
+		o.GUID.Value = item.GUID
+		if item.DecodedURL != "" {
+			o.GUID.IsPermaLink, o.GUID.Value = true, item.DecodedURL
+			o.OriginalLink = item.Link
+		} else if o.GUID.Value == "" {
+			o.GUID.Value = item.Link
+		}
+		if !item.Published.IsZero() {
+			o.PubDate = item.Published.UTC().Format(rssDateFormat)
+		}
+		if item.Source != "" {
+			o.Source = &rssSource{URL: item.SourceURL, Name: item.Source}
+		}
+		out.Channel.Items = append(out.Channel.Items, o)
+	}
+	return encodeXML(w, out)
+}
+
+type atomOutput struct {
+	XMLName xml.Name          `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string            `xml:"title"`
+	ID      string            `xml:"id"`
+	Updated string            `xml:"updated"`
+	Links   []atomLink        `xml:"link"`
+	Author  atomPerson        `xml:"author"`
+	Entries []atomOutputEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel   string `xml:"rel,attr"`
+	Type  string `xml:"type,attr,omitempty"`
+	Href  string `xml:"href,attr"`
+	Title string `xml:"title,attr,omitempty"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri,omitempty"`
+}
+
+type atomOutputEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published,omitempty"`
+	Links      []atomLink     `xml:"link"`
+	Author     *atomPerson    `xml:"author"`
+	Categories []atomCategory `xml:"category"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+func writeAtom(w io.Writer, feed *Feed) error {
+	out := atomOutput{
+		Title:   feed.Title,
+		ID:      feed.Link,
+		Updated: feedUpdated(feed).Format(time.RFC3339),
+		Author:  atomPerson{Name: "Google News"},
+	}
+	if out.ID == "" {
+		out.ID = DefaultEndpoints.FeedURL
+	}
+	if feed.Link != "" {
+		out.Links = append(out.Links, atomLink{Rel: "alternate", Href: feed.Link})
+	}
+
+	for _, item := range feed.Items {
+		entry := atomOutputEntry{Title: item.Title, ID: item.Link}
+		published := item.Published
+		if published.IsZero() {
+			published = feedUpdated(feed)
+		} else {
+			entry.Published = published.UTC().Format(time.RFC3339)
+		}
+		entry.Updated = published.UTC().Format(time.RFC3339)
+
+		if item.DecodedURL != "" {
+			// Both are alternates; the type tells the publisher page apart
+			entry.Links = append(entry.Links,
+				atomLink{Rel: "alternate", Type: "text/html", Href: item.DecodedURL},
+				atomLink{Rel: "alternate", Href: item.Link, Title: "Google News"})
+		} else {
+			entry.Links = append(entry.Links, atomLink{Rel: "alternate", Href: item.Link})
+		}
+		if item.Source != "" {
+			entry.Author = &atomPerson{Name: item.Source, URI: item.SourceURL}
+		}
+		for _, category := range item.Categories {
+			entry.Categories = append(entry.Categories, atomCategory{Term: category})
+		}
+		out.Entries = append(out.Entries, entry)
+	}
+	return encodeXML(w, out)
+}
+
+func encodeXML(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+type jsonFeedOutput struct {
+	Version     string               `json:"version"`
+	Title       string               `json:"title"`
+	HomePageURL string               `json:"home_page_url,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Language    string               `json:"language,omitempty"`
+	Items       []jsonFeedOutputItem `json:"items"`
+}
+
+type jsonFeedOutputItem struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url"`
+	ExternalURL   string           `json:"external_url,omitempty"`
+	Title         string           `json:"title,omitempty"`
+	ContentText   string           `json:"content_text"`
+	DatePublished string           `json:"date_published,omitempty"`
+	Authors       []jsonFeedAuthor `json:"authors,omitempty"`
+	Tags          []string         `json:"tags,omitempty"`
+	GNews         jsonFeedGNews    `json:"_gnews"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+// jsonFeedGNews is the _gnews extension of JSON Feed items
+type jsonFeedGNews struct {
+	OriginalLink string `json:"original_link"`
+	Error        string `json:"error,omitempty"`
+}
+
+func writeJSONFeed(w io.Writer, feed *Feed) error {
+	out := jsonFeedOutput{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       feed.Title,
+		HomePageURL: feed.Link,
+		Description: feed.Description,
+		Language:    feed.Language,
+		Items:       []jsonFeedOutputItem{},
+	}
+
+	for _, item := range feed.Items {
+		o := jsonFeedOutputItem{
+			ID:          item.Link,
+			URL:         itemURL(item),
+			Title:       item.Title,
+			ContentText: item.Title,
+			Tags:        item.Categories,
+			GNews:       jsonFeedGNews{OriginalLink: item.Link, Error: item.Error},
+		}
+		if item.DecodedURL != "" {
+			o.ExternalURL = item.Link
+		}
+		if !item.Published.IsZero() {
+			o.DatePublished = item.Published.UTC().Format(time.RFC3339)
+		}
+		if item.Source != "" {
+			o.Authors = []jsonFeedAuthor{{Name: item.Source, URL: item.SourceURL}}
+		}
+		out.Items = append(out.Items, o)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
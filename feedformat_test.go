@@ -0,0 +1,140 @@
+package gnewsdecoder_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	gnews "github.com/alainmucyo/google-news-url-decoder"
+)
+
+func testDecodedFeed() *gnews.Feed {
+	return &gnews.Feed{
+		Title: `"golang" - Google News`,
+		Link:  "https://news.google.com/search?q=golang",
+		Items: []gnews.FeedItem{
+			{
+				Title:      "Go 1.24 is released",
+				Link:       "https://news.google.com/rss/articles/CBMidecoded?oc=5",
+				DecodedURL: "https://go.dev/blog/go1.24?a=1&b=2",
+				GUID:       "CBMidecoded",
+				Published:  time.Date(2025, 2, 11, 17, 0, 0, 0, time.UTC),
+				Source:     "go.dev",
+				SourceURL:  "https://go.dev",
+			},
+			{
+				Title: "Undecoded",
+				Link:  "https://news.google.com/rss/articles/CBMiunknown?oc=5",
+				Error: "failed to fetch data",
+			},
+		},
+	}
+}
+
+func TestWriteFeed_Atom(t *testing.T) {
+	var out bytes.Buffer
+	if err := gnews.WriteFeed(&out, testDecodedFeed(), gnews.FormatAtom); err != nil {
+		t.Fatalf("WriteFeed() error = %v", err)
+	}
+
+	var feed struct {
+		XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		Entries []struct {
+			ID        string `xml:"id"`
+			Published string `xml:"published"`
+			Links     []struct {
+				Rel  string `xml:"rel,attr"`
+				Type string `xml:"type,attr"`
+				Href string `xml:"href,attr"`
+			} `xml:"link"`
+			Author struct {
+				Name string `xml:"name"`
+			} `xml:"author"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(out.Bytes(), &feed); err != nil {
+		t.Fatalf("Atom output is not valid XML: %v\n%s", err, out.String())
+	}
+	if len(feed.Entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(feed.Entries))
+	}
+
+	entry := feed.Entries[0]
+	if len(entry.Links) != 2 || entry.Links[0].Href != "https://go.dev/blog/go1.24?a=1&b=2" || entry.Links[0].Type != "text/html" ||
+		entry.Links[1].Rel != "alternate" || entry.Links[1].Href != "https://news.google.com/rss/articles/CBMidecoded?oc=5" {
+		t.Errorf("entries[0] links = %+v", entry.Links)
+	}
+	if entry.Published != "2025-02-11T17:00:00Z" || entry.Author.Name != "go.dev" {
+		t.Errorf("entries[0] = %+v", entry)
+	}
+	if links := feed.Entries[1].Links; len(links) != 1 || links[0].Href != "https://news.google.com/rss/articles/CBMiunknown?oc=5" {
+		t.Errorf("entries[1] links = %+v", links)
+	}
+}
+
+func TestWriteFeed_JSONFeed(t *testing.T) {
+	var out bytes.Buffer
+	if err := gnews.WriteFeed(&out, testDecodedFeed(), gnews.FormatJSONFeed); err != nil {
+		t.Fatalf("WriteFeed() error = %v", err)
+	}
+
+	var feed struct {
+		Version string `json:"version"`
+		Items   []struct {
+			URL           string `json:"url"`
+			ExternalURL   string `json:"external_url"`
+			DatePublished string `json:"date_published"`
+			Authors       []struct {
+				Name string `json:"name"`
+			} `json:"authors"`
+			GNews struct {
+				OriginalLink string `json:"original_link"`
+				Error        string `json:"error"`
+			} `json:"_gnews"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &feed); err != nil {
+		t.Fatalf("JSON Feed output is not valid JSON: %v", err)
+	}
+	if feed.Version != "https://jsonfeed.org/version/1.1" || len(feed.Items) != 2 {
+		t.Fatalf("feed = %+v", feed)
+	}
+
+	item := feed.Items[0]
+	if item.URL != "https://go.dev/blog/go1.24?a=1&b=2" || item.ExternalURL != "https://news.google.com/rss/articles/CBMidecoded?oc=5" ||
+		item.DatePublished != "2025-02-11T17:00:00Z" || len(item.Authors) != 1 || item.Authors[0].Name != "go.dev" {
+		t.Errorf("items[0] = %+v", item)
+	}
+	if item := feed.Items[1]; item.URL != item.GNews.OriginalLink || item.ExternalURL != "" || item.GNews.Error == "" {
+		t.Errorf("items[1] = %+v", item)
+	}
+}
+
+func TestWriteFeed_RSS(t *testing.T) {
+	var out bytes.Buffer
+	if err := gnews.WriteFeed(&out, testDecodedFeed(), gnews.FormatRSS); err != nil {
+		t.Fatalf("WriteFeed() error = %v", err)
+	}
+
+	// The output reads back as a feed carrying the decoded links
+	feed, err := gnews.ParseFeed(&out)
+	if err != nil {
+		t.Fatalf("ParseFeed() of RSS output error = %v", err)
+	}
+	if len(feed.Items) != 2 {
+		t.Fatalf("got %d items, want 2", len(feed.Items))
+	}
+	item := feed.Items[0]
+	if item.Link != "https://go.dev/blog/go1.24?a=1&b=2" || item.Source != "go.dev" || !item.Published.Equal(time.Date(2025, 2, 11, 17, 0, 0, 0, time.UTC)) {
+		t.Errorf("items[0] = %+v", item)
+	}
+	if feed.Items[1].Link != "https://news.google.com/rss/articles/CBMiunknown?oc=5" {
+		t.Errorf("items[1] link = %q", feed.Items[1].Link)
+	}
+
+	if _, err := gnews.ParseFeedFormat("yaml"); err == nil {
+		t.Error("ParseFeedFormat(yaml) succeeded, want error")
+	}
+}
@@ -0,0 +1,198 @@
+package gnewsdecoder
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Topic is a Google News headline section
+type Topic string
+
+// Google News headline sections
+const (
+	TopicWorld         Topic = "WORLD"
+	TopicNation        Topic = "NATION"
+	TopicBusiness      Topic = "BUSINESS"
+	TopicTechnology    Topic = "TECHNOLOGY"
+	TopicEntertainment Topic = "ENTERTAINMENT"
+	TopicSports        Topic = "SPORTS"
+	TopicScience       Topic = "SCIENCE"
+	TopicHealth        Topic = "HEALTH"
+)
+
+// FeedQuery builds Google News RSS feed URLs. Create one with SearchFeed,
+// TopStoriesFeed, TopicFeed, TopicIDFeed or GeoFeed, refine it with the
+// chainable methods and pass it to GoogleDecoder.FetchFeed or call URL.
+//
+//	q := gnews.SearchFeed("golang").Site("go.dev").When(24 * time.Hour).Edition(gnews.Locale{Edition: "FR:fr"})
+type FeedQuery struct {
+	path   string
+	terms  []string
+	locale *Locale
+	err    error
+}
+
+// SearchFeed returns a search feed query. The query may already use Google
+// News search operators; the methods of FeedQuery add more.
+func SearchFeed(query string) *FeedQuery {
+	q := &FeedQuery{path: "/search"}
+	if query = strings.TrimSpace(query); query != "" {
+		q.terms = append(q.terms, query)
+	}
+	return q
+}
+
+// TopStoriesFeed returns the top stories feed of an edition
+func TopStoriesFeed() *FeedQuery {
+	return &FeedQuery{}
+}
+
+// TopicFeed returns the feed of a headline section
+func TopicFeed(topic Topic) *FeedQuery {
+	q := &FeedQuery{path: "/headlines/section/topic/" + string(topic)}
+	switch topic {
+	case TopicWorld, TopicNation, TopicBusiness, TopicTechnology, TopicEntertainment, TopicSports, TopicScience, TopicHealth:
+	default:
+		q.err = fmt.Errorf("unknown topic %q", topic)
+	}
+	return q
+}
+
+// TopicIDFeed returns the feed of a topic page by its ID, the CAAq... part of a
+// news.google.com/topics/ URL
+func TopicIDFeed(id string) *FeedQuery {
+	q := &FeedQuery{path: "/topics/" + url.PathEscape(id)}
+	if err := validateArticleID(id); err != nil {
+		q.err = fmt.Errorf("invalid topic ID %q", id)
+	}
+	return q
+}
+
+// GeoFeed returns the local news feed of a location, e.g. "Berlin" or "Lyon, France"
+func GeoFeed(location string) *FeedQuery {
+	q := &FeedQuery{path: "/headlines/section/geo/" + url.PathEscape(strings.TrimSpace(location))}
+	if strings.TrimSpace(location) == "" {
+		q.err = errors.New("empty geo location")
+	}
+	return q
+}
+
+// Phrase requires the exact phrase
+func (q *FeedQuery) Phrase(phrase string) *FeedQuery {
+	return q.addTerm(`"` + strings.ReplaceAll(phrase, `"`, "") + `"`)
+}
+
+// Site restricts results to a domain, e.g. "reuters.com"
+func (q *FeedQuery) Site(domain string) *FeedQuery {
+	return q.addTerm("site:" + domain)
+}
+
+// InTitle requires a word in the article title
+func (q *FeedQuery) InTitle(word string) *FeedQuery {
+	return q.addTerm("intitle:" + word)
+}
+
+// Exclude removes results containing the term
+func (q *FeedQuery) Exclude(term string) *FeedQuery {
+	return q.addTerm("-" + quoteTerm(term))
+}
+
+// AnyOf requires at least one of the terms
+func (q *FeedQuery) AnyOf(terms ...string) *FeedQuery {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = quoteTerm(term)
+	}
+	if len(quoted) == 1 {
+		return q.addTerm(quoted[0])
+	}
+	return q.addTerm("(" + strings.Join(quoted, " OR ") + ")")
+}
+
+// When restricts results to the given period before now, in whole hours or days,
+// e.g. when:12h or when:7d
+func (q *FeedQuery) When(d time.Duration) *FeedQuery {
+	switch {
+	case d < time.Hour:
+		q.setErr(fmt.Errorf("when must be at least one hour, got %s", d))
+		return q
+	case d%(24*time.Hour) == 0:
+		return q.addTerm(fmt.Sprintf("when:%dd", d/(24*time.Hour)))
+	default:
+		return q.addTerm(fmt.Sprintf("when:%dh", (d+time.Hour-1)/time.Hour))
+	}
+}
+
+// After restricts results to articles published on or after the day of t
+func (q *FeedQuery) After(t time.Time) *FeedQuery {
+	return q.addTerm("after:" + t.Format("2006-01-02"))
+}
+
+// Before restricts results to articles published before the day of t
+func (q *FeedQuery) Before(t time.Time) *FeedQuery {
+	return q.addTerm("before:" + t.Format("2006-01-02"))
+}
+
+// Edition sets the hl, gl and ceid parameters of the feed. Missing fields are
+// derived as for WithLocale. By default the decoder's locale or DefaultLocale is used.
+func (q *FeedQuery) Edition(locale Locale) *FeedQuery {
+	locale = locale.complete()
+	if err := locale.Validate(); err != nil {
+		q.setErr(err)
+	}
+	q.locale = &locale
+	return q
+}
+
+// URL returns the feed URL on news.google.com
+func (q *FeedQuery) URL() (string, error) {
+	return q.build(DefaultEndpoints.FeedURL, nil)
+}
+
+// build returns the feed URL under the given root, using fallback when the query has no locale
+func (q *FeedQuery) build(root string, fallback *Locale) (string, error) {
+	if q.err != nil {
+		return "", q.err
+	}
+	if q.path == "/search" && len(q.terms) == 0 {
+		return "", errors.New("empty search query")
+	}
+	if q.path != "/search" && len(q.terms) > 0 {
+		return "", errors.New("search operators only apply to search feeds")
+	}
+
+	configured := q.locale
+	if configured == nil {
+		configured = fallback
+	}
+	locale := resolveLocale(configured, nil)
+
+	params := url.Values{"hl": {locale.Language}, "gl": {locale.Country}, "ceid": {locale.Edition}}
+	if len(q.terms) > 0 {
+		params.Set("q", strings.Join(q.terms, " "))
+	}
+	return strings.TrimRight(root, "/") + q.path + "?" + params.Encode(), nil
+}
+
+func (q *FeedQuery) addTerm(term string) *FeedQuery {
+	q.terms = append(q.terms, term)
+	return q
+}
+
+func (q *FeedQuery) setErr(err error) {
+	if q.err == nil {
+		q.err = err
+	}
+}
+
+// quoteTerm quotes terms containing spaces so operators apply to the whole term
+func quoteTerm(term string) string {
+	term = strings.TrimSpace(term)
+	if strings.ContainsAny(term, " \t") {
+		return `"` + strings.ReplaceAll(term, `"`, "") + `"`
+	}
+	return term
+}
@@ -0,0 +1,107 @@
+package gnewsdecoder_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	gnews "github.com/alainmucyo/google-news-url-decoder"
+	"github.com/alainmucyo/google-news-url-decoder/gnewstest"
+)
+
+func TestFeedQuery_URL(t *testing.T) {
+	day := time.Date(2025, 2, 10, 15, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		query *gnews.FeedQuery
+		want  string
+	}{
+		{"top stories", gnews.TopStoriesFeed(), "https://news.google.com/rss?ceid=US%3Aen&gl=US&hl=en-US"},
+		{"search", gnews.SearchFeed("golang"), "https://news.google.com/rss/search?ceid=US%3Aen&gl=US&hl=en-US&q=golang"},
+		{
+			"operators",
+			gnews.SearchFeed("golang").Phrase("generics proposal").Site("go.dev").InTitle("release").Exclude("rust lang").AnyOf("compiler", "runtime"),
+			"https://news.google.com/rss/search?ceid=US%3Aen&gl=US&hl=en-US&q=golang+%22generics+proposal%22+site%3Ago.dev+intitle%3Arelease+-%22rust+lang%22+%28compiler+OR+runtime%29",
+		},
+		{"when days", gnews.SearchFeed("go").When(7 * 24 * time.Hour), "https://news.google.com/rss/search?ceid=US%3Aen&gl=US&hl=en-US&q=go+when%3A7d"},
+		{"when hours", gnews.SearchFeed("go").When(90 * time.Minute), "https://news.google.com/rss/search?ceid=US%3Aen&gl=US&hl=en-US&q=go+when%3A2h"},
+		{"date range", gnews.SearchFeed("go").After(day).Before(day.AddDate(0, 0, 1)), "https://news.google.com/rss/search?ceid=US%3Aen&gl=US&hl=en-US&q=go+after%3A2025-02-10+before%3A2025-02-11"},
+		{"topic", gnews.TopicFeed(gnews.TopicTechnology), "https://news.google.com/rss/headlines/section/topic/TECHNOLOGY?ceid=US%3Aen&gl=US&hl=en-US"},
+		{"topic ID", gnews.TopicIDFeed("CAAqJggKIiBDQkFTRWdvSUwyMHZNRGRqTVhZU0FtVnVHZ0pWVXlnQVAB"), "https://news.google.com/rss/topics/CAAqJggKIiBDQkFTRWdvSUwyMHZNRGRqTVhZU0FtVnVHZ0pWVXlnQVAB?ceid=US%3Aen&gl=US&hl=en-US"},
+		{"geo", gnews.GeoFeed("Lyon, France"), "https://news.google.com/rss/headlines/section/geo/Lyon%2C%20France?ceid=US%3Aen&gl=US&hl=en-US"},
+		{"edition", gnews.TopStoriesFeed().Edition(gnews.Locale{Edition: "FR:fr"}), "https://news.google.com/rss?ceid=FR%3Afr&gl=FR&hl=fr"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.query.URL()
+			if err != nil {
+				t.Fatalf("URL() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("URL() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFeedQuery_Invalid(t *testing.T) {
+	for name, query := range map[string]*gnews.FeedQuery{
+		"empty search":          gnews.SearchFeed("  "),
+		"unknown topic":         gnews.TopicFeed("GOSSIP"),
+		"invalid topic ID":      gnews.TopicIDFeed("not/an id"),
+		"empty geo":             gnews.GeoFeed(""),
+		"short when":            gnews.SearchFeed("go").When(time.Minute),
+		"operators on topic":    gnews.TopicFeed(gnews.TopicWorld).Site("example.com"),
+		"invalid edition":       gnews.TopStoriesFeed().Edition(gnews.Locale{Edition: "FR fr"}),
+		"operators on top feed": gnews.TopStoriesFeed().When(time.Hour),
+	} {
+		if got, err := query.URL(); err == nil {
+			t.Errorf("%s: URL() = %s, want error", name, got)
+		}
+	}
+}
+
+func TestFetchFeed(t *testing.T) {
+	srv := gnewstest.NewServer()
+	defer srv.Close()
+
+	first := opaqueID("AU_yqLfetch1", "")
+	srv.AddArticle(first, "https://example.com/first")
+	srv.AddFeed("/rss/search", testFeed(first, "CBMiunknown"))
+
+	decoder, err := gnews.NewGoogleDecoder(srv.Option(), gnews.WithLocale(gnews.Locale{Edition: "DE:de"}))
+	if err != nil {
+		t.Fatalf("Failed to create GoogleDecoder: %v", err)
+	}
+
+	feed, err := decoder.FetchFeed(context.Background(), gnews.SearchFeed("golang"))
+	if err != nil {
+		t.Fatalf("FetchFeed() error = %v", err)
+	}
+	if feed.Title != `"golang" - Google News` || len(feed.Items) != 2 {
+		t.Fatalf("feed = %+v", feed)
+	}
+
+	item := feed.Items[0]
+	if item.DecodedURL != "https://example.com/first" || item.Error != "" {
+		t.Errorf("items[0] decoded = %q, error = %q", item.DecodedURL, item.Error)
+	}
+	if item.Source != "Example" || item.SourceURL != "https://example.com" || item.GUID != first {
+		t.Errorf("items[0] = %+v", item)
+	}
+	if !item.Published.Equal(time.Date(2025, 2, 10, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("items[0] published = %v", item.Published)
+	}
+	if item.Cluster == nil || item.Cluster.Primary.Title != "Story 1" {
+		t.Errorf("items[0] cluster = %+v", item.Cluster)
+	}
+	if feed.Items[1].DecodedURL != "" || feed.Items[1].Error == "" {
+		t.Errorf("items[1] = %+v, want a decode error", feed.Items[1])
+	}
+
+	if _, err := decoder.FetchFeed(context.Background(), gnews.TopicFeed(gnews.TopicWorld)); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("FetchFeed() of an unknown feed error = %v, want status 404", err)
+	}
+}
@@ -0,0 +1,113 @@
+package gnewsdecoder_test
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	gnews "github.com/alainmucyo/google-news-url-decoder"
+)
+
+// newFuzzDecoder returns a decoder whose every request is answered with body
+func newFuzzDecoder(t *testing.T, body string) *gnews.GoogleDecoder {
+	t.Helper()
+	decoder, err := gnews.NewGoogleDecoder(gnews.WithTransport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     make(http.Header),
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}, nil
+	})))
+	if err != nil {
+		t.Fatalf("Failed to create GoogleDecoder: %v", err)
+	}
+	return decoder
+}
+
+func FuzzDecoderV1(f *testing.F) {
+	f.Add("CBMiLmh0dHBzOi8vd3d3LmJiYy5jb20vbmV3cy9hcnRpY2xlcy9jampqbnhkdjE4OG_SATJodHRwczovL3d3dy5iYmMuY29tL25ld3MvYXJ0aWNsZXMvY2pqam54ZHYxODhvLmFtcA")
+	f.Add(opaqueID("AU_yqLabc", "AU_yqLamp"))
+	f.Add("CBMi")
+	f.Add("CBMi_____w")
+	f.Add("CBMiAQ==")
+	f.Add("CBMiAA")
+	f.Add("")
+
+	f.Fuzz(func(t *testing.T, id string) {
+		sourceURL := "https://news.google.com/rss/articles/" + id
+		if decoded := gnews.DecoderV1(sourceURL); decoded == "" {
+			t.Errorf("DecoderV1(%q) returned an empty string", sourceURL)
+		}
+	})
+}
+
+func FuzzArticleIDRoundTrip(f *testing.F) {
+	f.Add("https://www.bbc.com/news/articles/cjjjnxdv188o", "")
+	f.Add("https://example.com/story", "https://example.com/story.amp")
+	f.Add(strings.Repeat("x", 300), "")
+	f.Add("AU_yqLabc", "AU_yqLamp")
+
+	f.Fuzz(func(t *testing.T, articleURL, ampURL string) {
+		id, err := gnews.EncodeArticleID(articleURL, gnews.EncodeOptions{AMPURL: ampURL})
+		if articleURL == "" {
+			if err == nil {
+				t.Error("EncodeArticleID accepted an empty URL")
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("EncodeArticleID(%q) failed: %v", articleURL, err)
+		}
+
+		if decoded := gnews.DecoderV1("https://news.google.com/rss/articles/" + id); decoded != articleURL {
+			t.Errorf("DecoderV1(EncodeArticleID(%q)) = %q", articleURL, decoded)
+		}
+	})
+}
+
+func FuzzDataAttributes(f *testing.F) {
+	f.Add(`<div jscontroller="x" data-n-a-sg="sig123" data-n-a-ts="1700000000"></div>`)
+	f.Add(`<div data-n-a-sg="" data-n-a-ts=""></div>`)
+	f.Add(`<div data-n-a-sg="sig123"></div>`)
+	f.Add(`<c-wiz><div data-n-a-ts="1700000000" data-n-a-sg="a&quot;b"`)
+	f.Add("")
+
+	f.Fuzz(func(t *testing.T, page string) {
+		params := newFuzzDecoder(t, page).GetDecodingParams("CBMiabc")
+		if params.Status && (params.Signature == "" || params.Timestamp == "") {
+			t.Errorf("GetDecodingParams() = %+v, want signature and timestamp", params)
+		}
+	})
+}
+
+func FuzzBatchExecuteResponse(f *testing.F) {
+	f.Add(")]}'\n\n[[\"wrb.fr\",\"Fbv4je\",\"[\\\"garturlres\\\",\\\"https://example.com/story\\\",1]\",null,null,null,\"generic\"]]")
+	f.Add(")]}'\n\n[[\"wrb.fr\",\"Fbv4je\",null,null,null,[5],\"1\"]]")
+	f.Add(")]}'\n\n[[\"wrb.fr\",\"Fbv4je\",\"[\\\"garturlres")
+	f.Add(")]}'\n\n[[\"wrb.fr\",\"Fbv4je\",\"[]\"]]")
+	f.Add(")]}'\n\n[]")
+	f.Add(")]}'\n\n120\n[[\"wrb.fr\",\"Fbv4je\",\"[\\\"garturlres\\\",\\\"https://example.com/story\\\",1]\",null,null,null,\"1\"]]\n25\n[[\"e\",4,null,null,100]]")
+	f.Add(")]}'\n\n[[\"wrb.fr\",\"Fbv4je\",\"[\\\"garturlres\\\",\\\"javascript:alert(1)\\\",1]\",null,null,null,\"generic\"]]")
+	f.Add("\n\n")
+	f.Add("")
+
+	sourceURL := "https://news.google.com/rss/articles/" + opaqueID("AU_yqLabc", "")
+
+	f.Fuzz(func(t *testing.T, body string) {
+		decoder := newFuzzDecoder(t, body)
+
+		result := decoder.DecodeURL("sig123", "1700000000", "CBMiabc")
+		if result.Status && !strings.HasPrefix(result.DecodedURL, "http") {
+			t.Errorf("DecodeURL() = %+v, want an http(s) URL", result)
+		}
+		if !result.Status && result.Message == "" {
+			t.Errorf("DecodeURL() = %+v, want a failure message", result)
+		}
+		if results := decoder.DecodeBatch([]string{sourceURL, sourceURL}); len(results) != 2 {
+			t.Errorf("DecodeBatch() returned %d results, want 2", len(results))
+		}
+		decoder.DecodeV3(sourceURL)
+	})
+}
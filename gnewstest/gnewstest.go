@@ -0,0 +1,320 @@
+// Package gnewstest provides a fake Google News server for offline testing.
+//
+// The server imitates the article page, the RSS article page, the
+// batchexecute endpoint for both garturlreq variants and the RSS feeds
+// registered with AddFeed. Point a decoder at it
+// with the option returned by Server.Option:
+//
+//	srv := gnewstest.NewServer()
+//	defer srv.Close()
+//	srv.AddArticle("CBMi...", "https://example.com/story")
+//
+//	decoder, _ := gnews.NewGoogleDecoder(srv.Option())
+//	result := decoder.Decode("https://news.google.com/rss/articles/CBMi...", nil)
+package gnewstest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	gnews "github.com/alainmucyo/google-news-url-decoder"
+)
+
+// Timestamp is the data-n-a-ts value served on article pages
+const Timestamp = "1700000000"
+
+// Fault is an error condition the server can inject
+type Fault int
+
+const (
+	// FaultNone serves normal responses
+	FaultNone Fault = iota
+	// FaultRateLimit answers every request with 429 Too Many Requests
+	FaultRateLimit
+	// FaultCaptcha redirects every request to a CAPTCHA page under /sorry/
+	FaultCaptcha
+	// FaultConsentRedirect redirects every request to a cookie consent page
+	FaultConsentRedirect
+	// FaultMalformedJSON answers batchexecute calls with a truncated JSON body
+	FaultMalformedJSON
+)
+
+// Server is a fake Google News server
+type Server struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	articles   map[string]string
+	feeds      map[string]string
+	fault      Fault
+	faultCount int
+	delay      time.Duration
+	requests   int
+}
+
+// NewServer starts a fake Google News server. Call Close when done.
+func NewServer() *Server {
+	s := &Server{articles: make(map[string]string), feeds: make(map[string]string)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/articles/", s.handleArticle)
+	mux.HandleFunc("/rss/articles/", s.handleArticle)
+	mux.HandleFunc("/rss", s.handleFeed)
+	mux.HandleFunc("/rss/", s.handleFeed)
+	mux.HandleFunc("/_/DotsSplashUi/data/batchexecute", s.handleBatchExecute)
+	mux.HandleFunc("/sorry/", handleCaptcha)
+	mux.HandleFunc("/consent/", handleConsent)
+
+	s.Server = httptest.NewServer(s.middleware(mux))
+	return s
+}
+
+// Option returns a decoder option that points every endpoint at the server
+func (s *Server) Option() gnews.DecoderOption {
+	return gnews.WithBaseURL(s.URL)
+}
+
+// AddArticle registers the URL an article ID resolves to
+func (s *Server) AddArticle(id, articleURL string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.articles[id] = articleURL
+}
+
+// AddFeed registers the RSS document served at a feed path, e.g. "/rss/search".
+// Query parameters are ignored when matching requests. Calling it again
+// replaces the document and its ETag.
+func (s *Server) AddFeed(path, body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.feeds[path] = body
+}
+
+// Signature returns the data-n-a-sg value served for an article ID
+func Signature(id string) string {
+	return "sig_" + id
+}
+
+// InjectFault makes the next n requests fail with the given fault.
+// With n <= 0 the fault stays until InjectFault is called with FaultNone.
+func (s *Server) InjectFault(fault Fault, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fault = fault
+	s.faultCount = n
+}
+
+// SetDelay delays every response, e.g. to exercise timeouts and cancellation
+func (s *Server) SetDelay(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delay = d
+}
+
+// Requests returns the number of requests served so far
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// middleware counts requests and applies delays and faults
+func (s *Server) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests++
+		delay := s.delay
+		fault := s.fault
+		if fault != FaultNone && s.faultCount > 0 {
+			s.faultCount--
+			if s.faultCount == 0 {
+				s.fault = FaultNone
+			}
+		}
+		s.mu.Unlock()
+
+		if delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		// Pages the faults redirect to are always served
+		if strings.HasPrefix(r.URL.Path, "/sorry/") || strings.HasPrefix(r.URL.Path, "/consent/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		switch fault {
+		case FaultRateLimit:
+			http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+		case FaultCaptcha:
+			http.Redirect(w, r, "/sorry/index?continue="+url.QueryEscape(r.URL.String()), http.StatusFound)
+		case FaultConsentRedirect:
+			http.Redirect(w, r, "/consent/?continue="+url.QueryEscape(r.URL.String()), http.StatusFound)
+		case FaultMalformedJSON:
+			if strings.HasPrefix(r.URL.Path, "/_/") {
+				fmt.Fprint(w, ")]}'\n\n[[\"wrb.fr\",\"Fbv4je\",\"[\\\"garturlres")
+				return
+			}
+			next.ServeHTTP(w, r)
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// handleArticle serves the article page carrying the decoding signature and timestamp
+func (s *Server) handleArticle(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+
+	s.mu.Lock()
+	_, ok := s.articles[id]
+	s.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, `<!doctype html><html><body><c-wiz><div jscontroller="aLI87" data-n-a-id="%s" data-n-a-sg="%s" data-n-a-ts="%s"></div></c-wiz></body></html>`,
+		html.EscapeString(id), html.EscapeString(Signature(id)), Timestamp)
+}
+
+// handleFeed serves the feeds registered with AddFeed, with an ETag
+// derived from the body and 304 Not Modified for matching If-None-Match
+func (s *Server) handleFeed(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	body, ok := s.feeds[r.URL.Path]
+	s.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(body))
+	etag := fmt.Sprintf(`"%x"`, h.Sum64())
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	fmt.Fprint(w, body)
+}
+
+// handleBatchExecute answers garturlreq calls, both the signed and the legacy variant
+func (s *Server) handleBatchExecute(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var envelopes [][][]any
+	if err := json.Unmarshal([]byte(r.PostForm.Get("f.req")), &envelopes); err != nil || len(envelopes) != 1 {
+		http.Error(w, "invalid f.req", http.StatusBadRequest)
+		return
+	}
+
+	var entries []any
+	for _, envelope := range envelopes[0] {
+		tag := "generic"
+		if len(envelope) == 4 {
+			if t, ok := envelope[3].(string); ok {
+				tag = t
+			}
+		}
+		rpcID, _ := envelope[0].(string)
+
+		articleURL, ok := s.resolve(envelope)
+		if !ok {
+			entries = append(entries, []any{"wrb.fr", rpcID, nil, nil, nil, []any{5}, tag})
+			continue
+		}
+
+		inner, _ := marshal([]any{"garturlres", articleURL, 1})
+		entries = append(entries, []any{"wrb.fr", rpcID, inner, nil, nil, nil, tag})
+	}
+
+	body, err := marshal(entries)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	fmt.Fprintf(w, ")]}'\n\n%s", body)
+}
+
+// resolve looks up the URL requested by a garturlreq envelope.
+// Signed requests must carry the signature and timestamp served on the article page.
+func (s *Server) resolve(envelope []any) (string, bool) {
+	if len(envelope) < 2 {
+		return "", false
+	}
+	rawArgs, ok := envelope[1].(string)
+	if !ok {
+		return "", false
+	}
+
+	var args []any
+	if err := json.Unmarshal([]byte(rawArgs), &args); err != nil || len(args) < 3 || args[0] != "garturlreq" {
+		return "", false
+	}
+	id, ok := args[2].(string)
+	if !ok {
+		return "", false
+	}
+
+	if len(args) >= 5 {
+		ts, _ := args[3].(float64)
+		sig, _ := args[4].(string)
+		if fmt.Sprintf("%.0f", ts) != Timestamp || sig != Signature(id) {
+			return "", false
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	articleURL, ok := s.articles[id]
+	return articleURL, ok
+}
+
+func handleCaptcha(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, `<html><body><form id="captcha-form" action="/sorry/index"><div class="g-recaptcha"></div></form>`+
+		`Our systems have detected unusual traffic from your computer network.</body></html>`)
+}
+
+func handleConsent(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, `<html><body><form action="/consent/save" method="POST">Before you continue to Google</form></body></html>`)
+}
+
+// marshal encodes v as JSON without escaping HTML characters, like Google does
+func marshal(v any) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
@@ -0,0 +1,169 @@
+package gnewstest_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	gnews "github.com/alainmucyo/google-news-url-decoder"
+	"github.com/alainmucyo/google-news-url-decoder/gnewstest"
+)
+
+// opaqueID builds an article ID wrapping AU_yqL tokens, which needs batch execute to resolve
+func opaqueID(token string) string {
+	id, _ := gnews.EncodeArticleID(token, gnews.EncodeOptions{AMPURL: token})
+	return id
+}
+
+func newDecoder(t *testing.T, srv *gnewstest.Server) *gnews.GoogleDecoder {
+	t.Helper()
+	decoder, err := gnews.NewGoogleDecoder(srv.Option())
+	if err != nil {
+		t.Fatalf("Failed to create GoogleDecoder: %v", err)
+	}
+	return decoder
+}
+
+func TestServer_SignedDecode(t *testing.T) {
+	srv := gnewstest.NewServer()
+	defer srv.Close()
+	srv.AddArticle("CBMitest123", "https://example.com/story?a=1&b=2")
+
+	decoder := newDecoder(t, srv)
+
+	result := decoder.Decode("https://news.google.com/rss/articles/CBMitest123?oc=5", nil)
+	if !result.Status || result.DecodedURL != "https://example.com/story?a=1&b=2" {
+		t.Errorf("Decode() = %+v", result)
+	}
+
+	result = decoder.Decode("https://news.google.com/rss/articles/CBMiunknown", nil)
+	if result.Status {
+		t.Errorf("Decode() of unknown ID = %+v, want failure", result)
+	}
+}
+
+func TestServer_LegacyBatchDecode(t *testing.T) {
+	srv := gnewstest.NewServer()
+	defer srv.Close()
+
+	first, second := opaqueID("AU_yqLfirst1"), opaqueID("AU_yqLsecond")
+	srv.AddArticle(first, "https://example.com/first")
+	srv.AddArticle(second, "https://example.com/second")
+
+	decoder := newDecoder(t, srv)
+
+	result := decoder.DecodeV3("https://news.google.com/rss/articles/" + first)
+	if !result.Status || result.DecodedURL != "https://example.com/first" {
+		t.Errorf("DecodeV3() = %+v", result)
+	}
+
+	results := decoder.DecodeBatch([]string{
+		"https://news.google.com/rss/articles/" + first,
+		"https://news.google.com/read/" + second,
+	})
+	if results[0].DecodedURL != "https://example.com/first" || results[1].DecodedURL != "https://example.com/second" {
+		t.Errorf("DecodeBatch() = %+v", results)
+	}
+}
+
+func TestServer_BatchDecodeMatchesTags(t *testing.T) {
+	srv := gnewstest.NewServer()
+	defer srv.Close()
+
+	unknown, known := opaqueID("AU_yqLunknwn"), opaqueID("AU_yqLknown1")
+	srv.AddArticle(known, "https://example.com/known")
+
+	decoder := newDecoder(t, srv)
+
+	results := decoder.DecodeBatch([]string{
+		"https://news.google.com/rss/articles/" + unknown,
+		"https://news.google.com/rss/articles/" + known,
+		"https://news.google.com/rss/articles/" + known + "?oc=5",
+		"https://news.google.com/rss/articles/" + unknown,
+	})
+	for _, i := range []int{0, 3} {
+		if results[i].Status || results[i].Message == "" {
+			t.Errorf("DecodeBatch()[%d] = %+v, want a failure with a message", i, results[i])
+		}
+	}
+	for _, i := range []int{1, 2} {
+		if !results[i].Status || results[i].DecodedURL != "https://example.com/known" {
+			t.Errorf("DecodeBatch()[%d] = %+v, want https://example.com/known", i, results[i])
+		}
+	}
+	if srv.Requests() != 1 {
+		t.Errorf("DecodeBatch() made %d requests, want 1", srv.Requests())
+	}
+}
+
+func TestServer_Faults(t *testing.T) {
+	tests := []struct {
+		name  string
+		fault gnewstest.Fault
+	}{
+		{name: "Rate limit", fault: gnewstest.FaultRateLimit},
+		{name: "CAPTCHA", fault: gnewstest.FaultCaptcha},
+		{name: "Consent redirect", fault: gnewstest.FaultConsentRedirect},
+		{name: "Malformed JSON", fault: gnewstest.FaultMalformedJSON},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := gnewstest.NewServer()
+			defer srv.Close()
+			srv.AddArticle("CBMitest123", "https://example.com/story")
+
+			decoder := newDecoder(t, srv)
+			sourceURL := "https://news.google.com/rss/articles/CBMitest123"
+
+			srv.InjectFault(tt.fault, 0)
+			if result := decoder.Decode(sourceURL, nil); result.Status {
+				t.Errorf("Decode() = %+v, want failure", result)
+			}
+
+			srv.InjectFault(gnewstest.FaultNone, 0)
+			if result := decoder.Decode(sourceURL, nil); !result.Status {
+				t.Errorf("Decode() after clearing fault = %+v, want success", result)
+			}
+		})
+	}
+}
+
+func TestServer_FaultCount(t *testing.T) {
+	srv := gnewstest.NewServer()
+	defer srv.Close()
+	srv.AddArticle("CBMitest123", "https://example.com/story")
+
+	decoder := newDecoder(t, srv)
+	srv.InjectFault(gnewstest.FaultRateLimit, 1)
+
+	// The article page is rate limited once, then the RSS fallback succeeds
+	result := decoder.Decode("https://news.google.com/rss/articles/CBMitest123", nil)
+	if !result.Status {
+		t.Errorf("Decode() = %+v, want success after a single fault", result)
+	}
+	if got := srv.Requests(); got != 3 {
+		t.Errorf("Requests() = %d, want 3", got)
+	}
+}
+
+func TestServer_SlowResponses(t *testing.T) {
+	srv := gnewstest.NewServer()
+	defer srv.Close()
+	srv.AddArticle("CBMitest123", "https://example.com/story")
+	srv.SetDelay(time.Minute)
+
+	decoder := newDecoder(t, srv)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	result := decoder.DecodeWithContext(ctx, "https://news.google.com/rss/articles/CBMitest123", nil)
+	if result.Status {
+		t.Errorf("DecodeWithContext() = %+v, want failure", result)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("DecodeWithContext() took %v, want it to stop at the deadline", elapsed)
+	}
+}
@@ -0,0 +1,173 @@
+package gnewsdecoder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// defaultUserAgent is sent when no User-Agent or browser profile is configured
+const defaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/129.0.0.0 Safari/537.36"
+
+// BrowserProfile is a consistent set of headers identifying one browser
+type BrowserProfile struct {
+	Name            string            `json:"name,omitempty"`
+	UserAgent       string            `json:"user_agent"`
+	AcceptLanguage  string            `json:"accept_language,omitempty"`
+	SecCHUA         string            `json:"sec_ch_ua,omitempty"`
+	SecCHUAMobile   string            `json:"sec_ch_ua_mobile,omitempty"`
+	SecCHUAPlatform string            `json:"sec_ch_ua_platform,omitempty"`
+	Headers         map[string]string `json:"headers,omitempty"`
+}
+
+// apply sets the profile headers on the request.
+// An Accept-Language set for an explicit locale is kept.
+func (p *BrowserProfile) apply(header http.Header) {
+	header.Set("User-Agent", p.UserAgent)
+	if p.AcceptLanguage != "" && header.Get("Accept-Language") == "" {
+		header.Set("Accept-Language", p.AcceptLanguage)
+	}
+	if p.SecCHUA != "" {
+		header.Set("Sec-CH-UA", p.SecCHUA)
+	}
+	if p.SecCHUAMobile != "" {
+		header.Set("Sec-CH-UA-Mobile", p.SecCHUAMobile)
+	}
+	if p.SecCHUAPlatform != "" {
+		header.Set("Sec-CH-UA-Platform", p.SecCHUAPlatform)
+	}
+	for k, v := range p.Headers {
+		header.Set(k, v)
+	}
+}
+
+// ProfileRotation controls how often the browser profile changes
+type ProfileRotation int
+
+const (
+	// RotatePerSession uses one profile for all requests of a decode
+	RotatePerSession ProfileRotation = iota
+	// RotatePerRequest switches profile on every request
+	RotatePerRequest
+)
+
+// LoadBrowserProfiles reads a JSON array of browser profiles
+func LoadBrowserProfiles(r io.Reader) ([]BrowserProfile, error) {
+	var profiles []BrowserProfile
+	if err := json.NewDecoder(r).Decode(&profiles); err != nil {
+		return nil, fmt.Errorf("failed to parse browser profiles: %v", err)
+	}
+	if len(profiles) == 0 {
+		return nil, errors.New("no browser profiles found")
+	}
+	return profiles, nil
+}
+
+// WithUserAgent sets the User-Agent sent with every request.
+// It overrides the User-Agent of browser profiles.
+func WithUserAgent(userAgent string) DecoderOption {
+	return func(d *GoogleDecoder) {
+		d.userAgent = userAgent
+	}
+}
+
+// WithHeaders sets extra headers sent with every request.
+// They take precedence over browser profiles and WithUserAgent.
+func WithHeaders(headers http.Header) DecoderOption {
+	return func(d *GoogleDecoder) {
+		if d.headers == nil {
+			d.headers = http.Header{}
+		}
+		for k, v := range headers {
+			d.headers[http.CanonicalHeaderKey(k)] = append([]string(nil), v...)
+		}
+	}
+}
+
+// WithBrowserProfiles rotates requests through the given browser profiles,
+// either once per decode or on every request.
+func WithBrowserProfiles(profiles []BrowserProfile, rotation ProfileRotation) DecoderOption {
+	return func(d *GoogleDecoder) {
+		d.profiles = profiles
+		d.profileRotation = rotation
+	}
+}
+
+// identityTransport sets the User-Agent and identifying headers on outgoing requests
+type identityTransport struct {
+	next      http.RoundTripper
+	userAgent string
+	headers   http.Header
+	profiles  []BrowserProfile
+	rotation  ProfileRotation
+
+	mu     sync.Mutex
+	cursor int
+}
+
+// newIdentityTransport validates the identity configuration and wraps next with it
+func newIdentityTransport(next http.RoundTripper, userAgent string, headers http.Header, profiles []BrowserProfile, rotation ProfileRotation) (*identityTransport, error) {
+	for i, p := range profiles {
+		if p.UserAgent == "" {
+			return nil, fmt.Errorf("browser profile %d has no user agent", i)
+		}
+	}
+	switch rotation {
+	case RotatePerSession, RotatePerRequest:
+	default:
+		return nil, fmt.Errorf("unknown profile rotation: %d", rotation)
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &identityTransport{
+		next:      next,
+		userAgent: userAgent,
+		headers:   headers,
+		profiles:  profiles,
+		rotation:  rotation,
+	}, nil
+}
+
+// RoundTrip applies, in increasing precedence, the browser profile,
+// the configured User-Agent and the extra headers.
+func (t *identityTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+
+	if profile := t.profile(req); profile != nil {
+		profile.apply(req.Header)
+	}
+	if t.userAgent != "" {
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+	for k, v := range t.headers {
+		req.Header[k] = v
+	}
+
+	return t.next.RoundTrip(req)
+}
+
+// profile returns the browser profile for the request, if any
+func (t *identityTransport) profile(req *http.Request) *BrowserProfile {
+	if len(t.profiles) == 0 {
+		return nil
+	}
+
+	session := decodeSessionFrom(req.Context())
+	if t.rotation == RotatePerSession && session != nil && session.profile != nil {
+		return session.profile
+	}
+
+	t.mu.Lock()
+	profile := &t.profiles[t.cursor%len(t.profiles)]
+	t.cursor++
+	t.mu.Unlock()
+
+	if session != nil {
+		session.profile = profile
+	}
+	return profile
+}
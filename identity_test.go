@@ -0,0 +1,207 @@
+package gnewsdecoder_test
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	gnews "github.com/alainmucyo/google-news-url-decoder"
+	"github.com/alainmucyo/google-news-url-decoder/gnewstest"
+)
+
+// headerRecorder is a transport that records request headers and passes
+// them to next, or answers 404 without one
+type headerRecorder struct {
+	mu      sync.Mutex
+	headers []http.Header
+	next    http.RoundTripper
+}
+
+func (r *headerRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.mu.Lock()
+	r.headers = append(r.headers, req.Header.Clone())
+	r.mu.Unlock()
+	if r.next != nil {
+		return r.next.RoundTrip(req)
+	}
+	return &http.Response{
+		StatusCode: http.StatusNotFound,
+		Body:       io.NopCloser(strings.NewReader("")),
+		Request:    req,
+	}, nil
+}
+
+func (r *headerRecorder) userAgents() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var uas []string
+	for _, h := range r.headers {
+		uas = append(uas, h.Get("User-Agent"))
+	}
+	return uas
+}
+
+var testProfiles = []gnews.BrowserProfile{
+	{Name: "chrome", UserAgent: "chrome-ua", AcceptLanguage: "en-US,en;q=0.9", SecCHUA: `"Chromium";v="129"`},
+	{Name: "firefox", UserAgent: "firefox-ua", AcceptLanguage: "fr-FR,fr;q=0.8"},
+}
+
+const testSourceURL = "https://news.google.com/rss/articles/CBMitest123"
+
+func TestIdentity_ProfilesPerSession(t *testing.T) {
+	rec := &headerRecorder{}
+	decoder, err := gnews.NewGoogleDecoder(
+		gnews.WithTransport(rec),
+		gnews.WithBrowserProfiles(testProfiles, gnews.RotatePerSession),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create GoogleDecoder: %v", err)
+	}
+
+	decoder.Decode(testSourceURL, nil)
+	decoder.Decode(testSourceURL, nil)
+
+	got := strings.Join(rec.userAgents(), ",")
+	want := "chrome-ua,chrome-ua,firefox-ua,firefox-ua"
+	if got != want {
+		t.Errorf("User-Agents = %s, want %s", got, want)
+	}
+	if chua := rec.headers[0].Get("Sec-CH-UA"); chua != `"Chromium";v="129"` {
+		t.Errorf("Sec-CH-UA = %q, want the chrome profile's", chua)
+	}
+	// Without an explicit locale the profile's Accept-Language is sent
+	for i, h := range rec.headers {
+		if lang, want := h.Get("Accept-Language"), testProfiles[i/2].AcceptLanguage; lang != want {
+			t.Errorf("request %d: Accept-Language = %q, want the profile's %q", i, lang, want)
+		}
+	}
+}
+
+func TestIdentity_ExplicitLocaleOverridesProfile(t *testing.T) {
+	srv := gnewstest.NewServer()
+	defer srv.Close()
+	id := opaqueID("AU_yqLlocale", "")
+	srv.AddArticle(id, "https://example.com/locale")
+	sourceURL := "https://news.google.com/rss/articles/" + id
+
+	profiles := []gnews.BrowserProfile{{UserAgent: "FRUA", AcceptLanguage: "fr-FR"}}
+	tests := []struct {
+		name      string
+		opts      []gnews.DecoderOption
+		sourceURL string
+		want      string
+	}{
+		{name: "no locale", sourceURL: sourceURL, want: "fr-FR"},
+		{name: "source URL locale", sourceURL: sourceURL + "?hl=de&gl=DE&ceid=DE:de", want: "de-DE,de;q=0.9"},
+		{name: "WithLocale", opts: []gnews.DecoderOption{gnews.WithLocale(gnews.Locale{Language: "en-GB", Country: "GB", Edition: "GB:en"})}, sourceURL: sourceURL, want: "en-GB,en;q=0.9"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &headerRecorder{}
+			opts := append([]gnews.DecoderOption{
+				srv.Option(),
+				gnews.WithBrowserProfiles(profiles, gnews.RotatePerSession),
+				gnews.WithMiddleware(func(next http.RoundTripper) http.RoundTripper {
+					rec.next = next
+					return rec
+				}),
+			}, tt.opts...)
+			decoder, err := gnews.NewGoogleDecoder(opts...)
+			if err != nil {
+				t.Fatalf("Failed to create GoogleDecoder: %v", err)
+			}
+
+			// The article page and the signed call both carry the headers
+			if result := decoder.Decode(tt.sourceURL, nil); !result.Status || len(rec.headers) != 2 {
+				t.Fatalf("Decode() = %+v after %d requests, want a decode in 2", result, len(rec.headers))
+			}
+			for i, h := range rec.headers {
+				if ua, lang := h.Get("User-Agent"), h.Get("Accept-Language"); ua != "FRUA" || lang != tt.want {
+					t.Errorf("request %d: User-Agent, Accept-Language = %q, %q, want FRUA, %q", i, ua, lang, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestIdentity_ProfilesPerRequest(t *testing.T) {
+	rec := &headerRecorder{}
+	decoder, err := gnews.NewGoogleDecoder(
+		gnews.WithTransport(rec),
+		gnews.WithBrowserProfiles(testProfiles, gnews.RotatePerRequest),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create GoogleDecoder: %v", err)
+	}
+
+	decoder.Decode(testSourceURL, nil)
+
+	got := strings.Join(rec.userAgents(), ",")
+	if got != "chrome-ua,firefox-ua" {
+		t.Errorf("User-Agents = %s, want chrome-ua,firefox-ua", got)
+	}
+}
+
+func TestIdentity_UserAgentAndHeaders(t *testing.T) {
+	rec := &headerRecorder{}
+	decoder, err := gnews.NewGoogleDecoder(
+		gnews.WithTransport(rec),
+		gnews.WithBrowserProfiles(testProfiles, gnews.RotatePerSession),
+		gnews.WithUserAgent("custom-ua"),
+		gnews.WithHeaders(http.Header{"x-team": {"ingest"}}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create GoogleDecoder: %v", err)
+	}
+
+	decoder.Decode(testSourceURL, nil)
+
+	for _, h := range rec.headers {
+		if ua := h.Get("User-Agent"); ua != "custom-ua" {
+			t.Errorf("User-Agent = %q, want custom-ua", ua)
+		}
+		if team := h.Get("X-Team"); team != "ingest" {
+			t.Errorf("X-Team = %q, want ingest", team)
+		}
+	}
+}
+
+func TestIdentity_DefaultUserAgent(t *testing.T) {
+	rec := &headerRecorder{}
+	decoder, err := gnews.NewGoogleDecoder(gnews.WithTransport(rec))
+	if err != nil {
+		t.Fatalf("Failed to create GoogleDecoder: %v", err)
+	}
+
+	decoder.Decode(testSourceURL, nil)
+
+	for _, ua := range rec.userAgents() {
+		if !strings.HasPrefix(ua, "Mozilla/5.0") {
+			t.Errorf("User-Agent = %q, want a browser User-Agent", ua)
+		}
+	}
+}
+
+func TestLoadBrowserProfiles(t *testing.T) {
+	profiles, err := gnews.LoadBrowserProfiles(strings.NewReader(`[
+		{"name": "chrome", "user_agent": "chrome-ua", "sec_ch_ua_platform": "\"Windows\""}
+	]`))
+	if err != nil {
+		t.Fatalf("LoadBrowserProfiles() error = %v", err)
+	}
+	if len(profiles) != 1 || profiles[0].UserAgent != "chrome-ua" || profiles[0].SecCHUAPlatform != `"Windows"` {
+		t.Errorf("LoadBrowserProfiles() = %+v", profiles)
+	}
+
+	if _, err := gnews.LoadBrowserProfiles(strings.NewReader(`[]`)); err == nil {
+		t.Error("Expected error for empty profile list")
+	}
+
+	_, err = gnews.NewGoogleDecoder(gnews.WithBrowserProfiles([]gnews.BrowserProfile{{Name: "empty"}}, gnews.RotatePerSession))
+	if err == nil {
+		t.Error("Expected error for profile without user agent")
+	}
+}
@@ -0,0 +1,182 @@
+package gnewsdecoder
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ArticleIDKind classifies what an article ID carries
+type ArticleIDKind string
+
+const (
+	// KindInline IDs embed the article URL
+	KindInline ArticleIDKind = "inline"
+	// KindInlineAMP IDs embed the article URL and its AMP URL
+	KindInlineAMP ArticleIDKind = "inline+amp"
+	// KindOpaque IDs embed an AU_yqL token that only Google can resolve
+	KindOpaque ArticleIDKind = "opaque"
+	// KindUnknown IDs have no recognizable URL field
+	KindUnknown ArticleIDKind = "unknown"
+)
+
+// DecodeMethod names the way an article URL is recovered
+type DecodeMethod string
+
+const (
+	// MethodOffline reads the URL from the article ID itself
+	MethodOffline DecodeMethod = "offline"
+	// MethodBatchExecute resolves an AU_yqL token with a legacy garturlreq call
+	MethodBatchExecute DecodeMethod = "batchexecute"
+	// MethodSigned fetches the article page and sends a signed garturlreq call
+	MethodSigned DecodeMethod = "signed"
+)
+
+// ArticleIDField is one protobuf field of an article ID
+type ArticleIDField struct {
+	Number   int    `json:"number"`
+	WireType string `json:"wire_type"`
+	// Value is the number for varint and fixed fields, the text for printable
+	// length-delimited fields and hex otherwise, as indicated by Encoding.
+	Value    string `json:"value"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// ArticleIDInfo describes the decoded structure of an article ID
+type ArticleIDInfo struct {
+	ID     string           `json:"id"`
+	Base64 string           `json:"base64"`
+	Hex    string           `json:"hex"`
+	Fields []ArticleIDField `json:"fields"`
+	Error  string           `json:"error,omitempty"`
+	Kind   ArticleIDKind    `json:"kind"`
+	// Method is how Decode resolves the ID, empty if it can't
+	Method DecodeMethod `json:"method,omitempty"`
+	// BatchMethod is how DecodeV3 and DecodeBatch resolve the ID, empty if they can't
+	BatchMethod DecodeMethod `json:"batch_method,omitempty"`
+	// OfflineDecodable reports whether WithOfflineOnly decoders can read the
+	// URL from the ID itself
+	OfflineDecodable bool `json:"offline_decodable"`
+}
+
+// InspectArticleID decodes a Google News article URL or bare article ID into its
+// protobuf fields without any network access. Damaged IDs are reported in
+// ArticleIDInfo.Error along with the fields parsed before the damage; an error is
+// only returned if no ID can be extracted or it is not base64url, padded or not,
+// like the decoders require.
+func InspectArticleID(urlOrID string) (ArticleIDInfo, error) {
+	id, err := articleIDFromInput(urlOrID)
+	if err != nil {
+		return ArticleIDInfo{}, err
+	}
+
+	unpadded := strings.TrimRight(id, "=")
+	if err := validateArticleID(unpadded); err != nil {
+		return ArticleIDInfo{}, err
+	}
+	raw, err := decodeArticleIDBytes(id)
+	if err != nil {
+		return ArticleIDInfo{}, fmt.Errorf("failed to decode base64: %v", err)
+	}
+
+	info := ArticleIDInfo{ID: id, Base64: "url (unpadded)", Hex: hex.EncodeToString(raw), Fields: []ArticleIDField{}, Kind: KindUnknown}
+	// Network methods send the ID as is and only take it unpadded
+	padded := unpadded != id
+	if padded {
+		info.Base64 = "url (padded)"
+	} else {
+		info.Method = MethodSigned
+	}
+
+	fields, err := parseProtoFields(raw)
+	if err != nil {
+		info.Error = err.Error()
+	}
+
+	var articleURL, ampURL string
+	for _, field := range fields {
+		info.Fields = append(info.Fields, describeField(field))
+		if field.WireType != wireBytes {
+			continue
+		}
+		switch {
+		case field.Number == articleIDURLField && articleURL == "":
+			articleURL = string(field.Bytes)
+		case field.Number == articleIDAMPField && ampURL == "":
+			ampURL = string(field.Bytes)
+		}
+	}
+
+	switch {
+	case articleURL == "":
+		info.Kind = KindUnknown
+	case strings.HasPrefix(articleURL, "AU_yqL"):
+		info.Kind = KindOpaque
+		if !padded {
+			info.BatchMethod = MethodBatchExecute
+		}
+	case ampURL != "":
+		info.Kind, info.BatchMethod, info.OfflineDecodable = KindInlineAMP, MethodOffline, true
+	default:
+		info.Kind, info.BatchMethod, info.OfflineDecodable = KindInline, MethodOffline, true
+	}
+	return info, nil
+}
+
+// articleIDFromInput returns the article ID of a Google News URL, or the input itself if it is a bare ID
+func articleIDFromInput(input string) (string, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return "", errors.New("empty article ID")
+	}
+	if !strings.Contains(input, "/") || !strings.Contains(input, ".") {
+		return input, nil
+	}
+
+	c, _, err := classifyArticle(input)
+	if err != nil {
+		return "", err
+	}
+	return c.ID, nil
+}
+
+// describeField formats a protobuf field for display
+func describeField(field protoField) ArticleIDField {
+	f := ArticleIDField{Number: field.Number}
+	switch field.WireType {
+	case wireVarint:
+		f.WireType = "varint"
+		f.Value = strconv.FormatUint(field.Varint, 10)
+	case wireFixed64:
+		f.WireType = "fixed64"
+		f.Value = strconv.FormatUint(field.Varint, 10)
+	case wireFixed32:
+		f.WireType = "fixed32"
+		f.Value = strconv.FormatUint(field.Varint, 10)
+	case wireBytes:
+		f.WireType = "bytes"
+		if isPrintable(field.Bytes) {
+			f.Value = string(field.Bytes)
+		} else {
+			f.Value, f.Encoding = hex.EncodeToString(field.Bytes), "hex"
+		}
+	}
+	return f
+}
+
+// isPrintable reports whether b is valid UTF-8 text without control characters
+func isPrintable(b []byte) bool {
+	if !utf8.Valid(b) {
+		return false
+	}
+	for _, r := range string(b) {
+		if !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+package gnewsdecoder_test
+
+import (
+	"testing"
+
+	gnews "github.com/alainmucyo/google-news-url-decoder"
+)
+
+func TestInspectArticleID(t *testing.T) {
+	inline, _ := gnews.EncodeArticleID("https://example.com/story", gnews.EncodeOptions{})
+
+	tests := []struct {
+		name      string
+		input     string
+		kind      gnews.ArticleIDKind
+		method    gnews.DecodeMethod
+		batch     gnews.DecodeMethod
+		offline   bool
+		fields    int
+		wantError bool
+	}{
+		{
+			name:    "inline with AMP",
+			input:   "https://news.google.com/rss/articles/CBMiLmh0dHBzOi8vd3d3LmJiYy5jb20vbmV3cy9hcnRpY2xlcy9jampqbnhkdjE4OG_SATJodHRwczovL3d3dy5iYmMuY29tL25ld3MvYXJ0aWNsZXMvY2pqam54ZHYxODhvLmFtcA?oc=5",
+			kind:    gnews.KindInlineAMP,
+			method:  gnews.MethodSigned,
+			batch:   gnews.MethodOffline,
+			offline: true,
+			fields:  3,
+		},
+		{name: "bare inline ID", input: inline, kind: gnews.KindInline, method: gnews.MethodSigned, batch: gnews.MethodOffline, offline: true, fields: 2},
+		{name: "padded inline ID", input: inline + "==", kind: gnews.KindInline, batch: gnews.MethodOffline, offline: true, fields: 2},
+		{name: "opaque", input: "https://news.google.com/read/" + opaqueID("AU_yqLabc", ""), kind: gnews.KindOpaque, method: gnews.MethodSigned, batch: gnews.MethodBatchExecute, fields: 2},
+		{name: "padded opaque", input: opaqueID("AU_yqLabc", "") + "=", kind: gnews.KindOpaque, fields: 2},
+		{name: "truncated", input: "CBMi", kind: gnews.KindUnknown, method: gnews.MethodSigned, fields: 1, wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := gnews.InspectArticleID(tt.input)
+			if err != nil {
+				t.Fatalf("InspectArticleID() failed: %v", err)
+			}
+			if info.Kind != tt.kind || info.Method != tt.method || info.BatchMethod != tt.batch || info.OfflineDecodable != tt.offline {
+				t.Errorf("kind, method, batch method, offline = %q, %q, %q, %v, want %q, %q, %q, %v",
+					info.Kind, info.Method, info.BatchMethod, info.OfflineDecodable, tt.kind, tt.method, tt.batch, tt.offline)
+			}
+			if len(info.Fields) != tt.fields {
+				t.Errorf("got %d fields, want %d: %+v", len(info.Fields), tt.fields, info.Fields)
+			}
+			if (info.Error != "") != tt.wantError {
+				t.Errorf("Error = %q, wantError %v", info.Error, tt.wantError)
+			}
+		})
+	}
+
+	info, _ := gnews.InspectArticleID(inline)
+	if info.Base64 != "url (unpadded)" || info.Hex[:6] != "081322" {
+		t.Errorf("Base64, Hex = %q, %q", info.Base64, info.Hex)
+	}
+	if f := info.Fields[1]; f.Number != 4 || f.WireType != "bytes" || f.Value != "https://example.com/story" {
+		t.Errorf("Fields[1] = %+v", f)
+	}
+
+	// Padded IDs inspect as the decoders read them
+	if info, err := gnews.InspectArticleID(inline + "=="); err != nil || info.Base64 != "url (padded)" {
+		t.Errorf("InspectArticleID(padded) = %+v, %v", info, err)
+	}
+	if result := gnews.DecoderV3("https://news.google.com/rss/articles/" + inline + "=="); !result.Status || result.Method != gnews.MethodOffline {
+		t.Errorf("DecoderV3(padded) = %+v, want an offline decode", result)
+	}
+
+	// Standard base64 is rejected, as the decoders reject it
+	for _, input := range []string{"", "CB!Mi", "CBMi+/8A", "https://example.com/not/an/article"} {
+		if _, err := gnews.InspectArticleID(input); err == nil {
+			t.Errorf("InspectArticleID(%q) succeeded, want error", input)
+		}
+	}
+}
@@ -0,0 +1,378 @@
+package gnewsdecoder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// DefaultJSONBatchSize is the number of documents RewriteJSON decodes together
+const DefaultJSONBatchSize = 100
+
+// JSONRewriteOptions configures RewriteJSON
+type JSONRewriteOptions struct {
+	// Path selects the string values to decode, in a JSONPath subset:
+	// $, .key, ['key'], [n], [*], .* and ..key for any depth,
+	// e.g. "$.items[*].link" or "$..url"
+	Path string
+	// Into is the key the publisher URL is written to, next to the matched
+	// value, which is kept. When empty the matched value is replaced.
+	Into string
+	// BatchSize is the number of documents whose links are decoded together,
+	// DefaultJSONBatchSize by default
+	BatchSize int
+}
+
+// RewriteJSON reads a stream of JSON documents, such as a single document
+// or NDJSON, decodes the Google News links selected by the path and writes
+// every document back, one per line, with the publisher URLs. Links are
+// decoded with a ConcurrentDecoder a batch of documents at a time, each
+// distinct link once, and documents are written as soon as their batch is
+// done. Everything but the rewritten values, including key order and
+// formatting inside documents, is copied unchanged. Links that fail to decode
+// are left as they were. The result of every distinct link is returned in
+// document order.
+func RewriteJSON(ctx context.Context, r io.Reader, w io.Writer, decoder *GoogleDecoder, opts JSONRewriteOptions) ([]LinkResult, error) {
+	path, err := parseJSONPath(opts.Path)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Into != "" && !path.endsWithKey() {
+		return nil, fmt.Errorf("path %q must end with a key to write into %q", opts.Path, opts.Into)
+	}
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultJSONBatchSize
+	}
+
+	var results []LinkResult
+	index := make(map[string]int)
+	cd := NewConcurrentDecoder(decoder, 0)
+
+	dec := json.NewDecoder(r)
+	for n := 0; ; {
+		// Read a batch of documents
+		var docs []json.RawMessage
+		var docMatches [][]jsonMatch
+		var eof bool
+		for len(docs) < batchSize {
+			var doc json.RawMessage
+			if err := dec.Decode(&doc); err != nil {
+				if errors.Is(err, io.EOF) {
+					eof = true
+					break
+				}
+				return results, fmt.Errorf("document %d: %v", n+1, err)
+			}
+			n++
+			matches, err := scanJSON(doc, path, opts.Into)
+			if err != nil {
+				return results, fmt.Errorf("document %d: %v", n, err)
+			}
+			docs = append(docs, doc)
+			docMatches = append(docMatches, matches)
+		}
+
+		// Decode the links not seen in earlier batches
+		var links []string
+		for _, matches := range docMatches {
+			for _, m := range matches {
+				if i, ok := index[m.link]; ok {
+					results[i].Count++
+					continue
+				}
+				index[m.link] = len(results)
+				results = append(results, LinkResult{Link: m.link, Count: 1})
+				links = append(links, m.link)
+			}
+		}
+		first := len(results) - len(links)
+		for i, result := range cd.DecodeURLsWithContext(ctx, links, nil) {
+			if result.Status {
+				results[first+i].DecodedURL = result.DecodedURL
+			} else {
+				results[first+i].Error = result.Message
+			}
+		}
+
+		for i, doc := range docs {
+			out := applyEdits(doc, jsonEdits(docMatches[i], results, index, opts.Into))
+			if _, err := w.Write(append(out, '\n')); err != nil {
+				return results, fmt.Errorf("failed to write output: %v", err)
+			}
+		}
+		if eof {
+			return results, ctx.Err()
+		}
+	}
+}
+
+// jsonEdits returns the edits writing the decoded URLs of a document's matches
+func jsonEdits(matches []jsonMatch, results []LinkResult, index map[string]int, into string) []textEdit {
+	var edits []textEdit
+	written := make(map[int]bool)
+	for _, m := range matches {
+		decoded := results[index[m.link]].DecodedURL
+		if decoded == "" {
+			continue
+		}
+		value := jsonString(decoded)
+		switch {
+		case into == "":
+			edits = append(edits, textEdit{start: m.start, end: m.end, text: value})
+		case written[m.object]:
+			// Only the first match of an object is written
+		case m.intoStart >= 0:
+			written[m.object] = true
+			edits = append(edits, textEdit{start: m.intoStart, end: m.intoEnd, text: value})
+		default:
+			written[m.object] = true
+			edits = append(edits, textEdit{start: m.end, end: m.end, text: "," + jsonString(into) + ":" + value})
+		}
+	}
+	return edits
+}
+
+// jsonString encodes s as a JSON string without escaping HTML characters
+func jsonString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// jsonMatch is a Google News link selected in a document
+type jsonMatch struct {
+	// start and end delimit the string value, quotes included
+	start, end int64
+	link       string
+	// object identifies the enclosing object; intoStart and intoEnd delimit
+	// the value of its Into key, -1 when it has none
+	object             int
+	intoStart, intoEnd int64
+}
+
+// jsonFrame is an object or array being scanned
+type jsonFrame struct {
+	object    bool
+	id        int
+	expectKey bool
+	key       string
+	index     int
+	// start is the offset of the opening delimiter
+	start int64
+	// intoStart and intoEnd delimit the value of the Into key, once seen
+	intoStart, intoEnd int64
+	matches            []int
+}
+
+// scanJSON finds the Google News links selected by path in a document.
+// The Into key of the objects holding them is located as well.
+func scanJSON(doc []byte, path jsonPath, into string) ([]jsonMatch, error) {
+	var matches []jsonMatch
+	var stack []*jsonFrame
+	objects := 0
+
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.UseNumber()
+
+	// value records a scalar or container value spanning doc[start:end] in the current frame
+	value := func(start, end int64) {
+		if len(stack) == 0 {
+			return
+		}
+		top := stack[len(stack)-1]
+		if top.object {
+			if into != "" && top.key == into {
+				top.intoStart, top.intoEnd = start, end
+			}
+			top.expectKey = true
+		} else {
+			top.index++
+		}
+	}
+
+	for {
+		prev := dec.InputOffset()
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, end := skipJSONSeparators(doc, prev), dec.InputOffset()
+
+		switch t := tok.(type) {
+		case json.Delim:
+			switch t {
+			case '{', '[':
+				frame := &jsonFrame{object: t == '{', expectKey: t == '{', start: start, intoStart: -1, intoEnd: -1}
+				if frame.object {
+					frame.id = objects
+					objects++
+				}
+				stack = append(stack, frame)
+			case '}', ']':
+				frame := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				for _, i := range frame.matches {
+					matches[i].intoStart, matches[i].intoEnd = frame.intoStart, frame.intoEnd
+				}
+				value(frame.start, end)
+			}
+		case string:
+			top := (*jsonFrame)(nil)
+			if len(stack) > 0 {
+				top = stack[len(stack)-1]
+			}
+			if top != nil && top.object && top.expectKey {
+				top.key, top.expectKey = t, false
+				continue
+			}
+			if path.match(stack) && isArticleLink(t) {
+				m := jsonMatch{start: start, end: end, link: t, intoStart: -1, intoEnd: -1}
+				if top != nil && top.object {
+					m.object = top.id
+					top.matches = append(top.matches, len(matches))
+				}
+				matches = append(matches, m)
+			}
+			value(start, end)
+		default:
+			value(start, end)
+		}
+	}
+	return matches, nil
+}
+
+// skipJSONSeparators returns the offset of the first token byte at or after offset
+func skipJSONSeparators(doc []byte, offset int64) int64 {
+	for offset < int64(len(doc)) {
+		switch doc[offset] {
+		case ' ', '\t', '\n', '\r', ',', ':':
+			offset++
+		default:
+			return offset
+		}
+	}
+	return offset
+}
+
+// jsonPathSegment is one step of a jsonPath
+type jsonPathSegment struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+	// recursive segments match at any depth below the previous one
+	recursive bool
+}
+
+// jsonPath is a parsed JSONPath subset
+type jsonPath []jsonPathSegment
+
+// parseJSONPath parses $, .key, ['key'], [n], [*], .* and ..key steps
+func parseJSONPath(s string) (jsonPath, error) {
+	if !strings.HasPrefix(s, "$") {
+		return nil, fmt.Errorf("invalid path %q: must start with $", s)
+	}
+
+	var path jsonPath
+	rest := s[1:]
+	for rest != "" {
+		var seg jsonPathSegment
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			seg.recursive = true
+			rest = rest[2:]
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+		case strings.HasPrefix(rest, "["):
+		default:
+			return nil, fmt.Errorf("invalid path %q at %q", s, rest)
+		}
+
+		if strings.HasPrefix(rest, "[") {
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path %q: unclosed [", s)
+			}
+			inner := rest[1:end]
+			rest = rest[end+1:]
+			switch {
+			case inner == "*":
+				seg.wildcard = true
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				seg.key = inner[1 : len(inner)-1]
+			default:
+				i, err := strconv.Atoi(inner)
+				if err != nil || i < 0 {
+					return nil, fmt.Errorf("invalid path %q: bad index [%s]", s, inner)
+				}
+				seg.index, seg.isIndex = i, true
+			}
+		} else {
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			name := rest[:end]
+			rest = rest[end:]
+			if name == "" {
+				return nil, fmt.Errorf("invalid path %q: empty key", s)
+			}
+			if name == "*" {
+				seg.wildcard = true
+			} else {
+				seg.key = name
+			}
+		}
+		path = append(path, seg)
+	}
+	return path, nil
+}
+
+// endsWithKey reports whether the path selects object members by name
+func (p jsonPath) endsWithKey() bool {
+	return len(p) > 0 && !p[len(p)-1].wildcard && !p[len(p)-1].isIndex
+}
+
+// match reports whether the value at the current position of stack is selected
+func (p jsonPath) match(stack []*jsonFrame) bool {
+	return matchJSONPath(p, stack)
+}
+
+func matchJSONPath(segs []jsonPathSegment, stack []*jsonFrame) bool {
+	if len(segs) == 0 {
+		return len(stack) == 0
+	}
+	seg := segs[0]
+	if !seg.recursive {
+		return len(stack) > 0 && seg.matches(stack[0]) && matchJSONPath(segs[1:], stack[1:])
+	}
+	for i := range stack {
+		if seg.matches(stack[i]) && matchJSONPath(segs[1:], stack[i+1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether the segment selects the current member of frame
+func (s jsonPathSegment) matches(frame *jsonFrame) bool {
+	switch {
+	case s.wildcard:
+		return true
+	case s.isIndex:
+		return !frame.object && frame.index == s.index
+	default:
+		return frame.object && frame.key == s.key
+	}
+}
@@ -0,0 +1,106 @@
+package gnewsdecoder_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	gnews "github.com/alainmucyo/google-news-url-decoder"
+)
+
+func TestRewriteJSON(t *testing.T) {
+	decoder, link := newRewriteDecoder(t)
+	const decoded = `"https://example.com/a?x=1&y=2"`
+	unknown := "https://news.google.com/rss/articles/CBMiunknown"
+
+	tests := []struct {
+		name  string
+		opts  gnews.JSONRewriteOptions
+		input string
+		want  string
+	}{
+		{
+			name:  "in place",
+			opts:  gnews.JSONRewriteOptions{Path: "$.items[*].link"},
+			input: `{"z":1,"items":[{"title":"T","link":"` + link + `","n":2.50},{"link":"` + unknown + `"}],"link":"` + link + `"}`,
+			want:  `{"z":1,"items":[{"title":"T","link":` + decoded + `,"n":2.50},{"link":"` + unknown + `"}],"link":"` + link + `"}` + "\n",
+		},
+		{
+			name:  "into new key",
+			opts:  gnews.JSONRewriteOptions{Path: "$.items[*].link", Into: "decoded_link"},
+			input: "{\n  \"items\": [\n    {\"link\": \"" + link + "\", \"b\": true}\n  ]\n}",
+			want:  "{\n  \"items\": [\n    {\"link\": \"" + link + "\",\"decoded_link\":" + decoded + ", \"b\": true}\n  ]\n}\n",
+		},
+		{
+			name:  "into existing key",
+			opts:  gnews.JSONRewriteOptions{Path: "$..url", Into: "real"},
+			input: `{"a":{"real":null,"deep":{"url":"` + link + `","real":{"x":[1]}}}}`,
+			want:  `{"a":{"real":null,"deep":{"url":"` + link + `","real":` + decoded + `}}}` + "\n",
+		},
+		{
+			name:  "ndjson",
+			opts:  gnews.JSONRewriteOptions{Path: "$['link']", BatchSize: 1},
+			input: `{"link":"` + link + `"}` + "\n" + `{"link":"` + link + `","other":"` + link + `"}` + "\n",
+			want:  `{"link":` + decoded + `}` + "\n" + `{"link":` + decoded + `,"other":"` + link + `"}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			_, err := gnews.RewriteJSON(context.Background(), strings.NewReader(tt.input), &out, decoder, tt.opts)
+			if err != nil {
+				t.Fatalf("RewriteJSON() error = %v", err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("RewriteJSON() =\n%s\nwant\n%s", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRewriteJSON_Results(t *testing.T) {
+	decoder, link := newRewriteDecoder(t)
+
+	input := strings.Repeat(`{"link":"`+link+`"}`+"\n", 5) + `{"link":"https://news.google.com/rss/articles/CBMiunknown"}`
+	var out bytes.Buffer
+	results, err := gnews.RewriteJSON(context.Background(), strings.NewReader(input), &out, decoder,
+		gnews.JSONRewriteOptions{Path: "$.link", BatchSize: 2})
+	if err != nil {
+		t.Fatalf("RewriteJSON() error = %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("results = %+v, want 2 distinct links", results)
+	}
+	if results[0].Count != 5 || results[0].DecodedURL != "https://example.com/a?x=1&y=2" {
+		t.Errorf("results[0] = %+v", results[0])
+	}
+	if results[1].Error == "" {
+		t.Errorf("results[1] = %+v, want a decode error", results[1])
+	}
+}
+
+func TestRewriteJSON_Invalid(t *testing.T) {
+	decoder, _ := newRewriteDecoder(t)
+
+	tests := []struct {
+		name  string
+		opts  gnews.JSONRewriteOptions
+		input string
+	}{
+		{"no root", gnews.JSONRewriteOptions{Path: "items[*].link"}, `{}`},
+		{"bad index", gnews.JSONRewriteOptions{Path: "$.items[x]"}, `{}`},
+		{"into array element", gnews.JSONRewriteOptions{Path: "$.links[*]", Into: "decoded"}, `{}`},
+		{"malformed document", gnews.JSONRewriteOptions{Path: "$.link"}, `{"link": }`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if _, err := gnews.RewriteJSON(context.Background(), strings.NewReader(tt.input), &out, decoder, tt.opts); err == nil {
+				t.Error("RewriteJSON() error = nil, want an error")
+			}
+		})
+	}
+}
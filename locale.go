@@ -0,0 +1,152 @@
+package gnewsdecoder
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Locale identifies a Google News edition through its hl, gl and ceid parameters
+type Locale struct {
+	Language string // hl, e.g. "fr" or "en-US"
+	Country  string // gl, e.g. "FR"
+	Edition  string // ceid, e.g. "FR:fr"
+}
+
+// DefaultLocale is the US English edition, used when no locale is known
+var DefaultLocale = Locale{Language: "en-US", Country: "US", Edition: "US:en"}
+
+// WithLocale sets the edition used for page fetches and batchexecute payloads.
+// By default the locale is taken from the hl, gl and ceid parameters of the source URL.
+func WithLocale(locale Locale) DecoderOption {
+	return func(d *GoogleDecoder) {
+		d.locale = &locale
+	}
+}
+
+// LocaleFromURL extracts the locale from the hl, gl and ceid query parameters of a
+// Google News URL. Missing values are derived from the others where possible.
+// It reports false if the URL carries no valid locale.
+func LocaleFromURL(sourceURL string) (Locale, bool) {
+	parsedURL, err := url.Parse(sourceURL)
+	if err != nil {
+		return Locale{}, false
+	}
+	return localeFromQuery(parsedURL.Query())
+}
+
+func localeFromQuery(query url.Values) (Locale, bool) {
+	locale := Locale{
+		Language: query.Get("hl"),
+		Country:  query.Get("gl"),
+		Edition:  query.Get("ceid"),
+	}
+	if locale == (Locale{}) {
+		return Locale{}, false
+	}
+
+	locale = locale.complete()
+	if locale.Validate() != nil {
+		return Locale{}, false
+	}
+	return locale, true
+}
+
+// complete fills in missing fields from the ones that are set, falling back to DefaultLocale
+func (l Locale) complete() Locale {
+	// ceid is COUNTRY:lang
+	if country, lang, ok := strings.Cut(l.Edition, ":"); ok {
+		if l.Country == "" {
+			l.Country = country
+		}
+		if l.Language == "" {
+			l.Language = lang
+		}
+	}
+
+	if l.Country == "" {
+		if _, region, ok := strings.Cut(l.Language, "-"); ok {
+			l.Country = strings.ToUpper(region)
+		}
+	}
+
+	if l.Language == "" {
+		l.Language = DefaultLocale.Language
+	}
+	if l.Country == "" {
+		l.Country = DefaultLocale.Country
+	}
+	if l.Edition == "" {
+		base, _, _ := strings.Cut(l.Language, "-")
+		l.Edition = l.Country + ":" + base
+	}
+	return l
+}
+
+// Validate checks that every field only uses characters valid in a locale tag
+func (l Locale) Validate() error {
+	for _, field := range []struct{ name, value string }{
+		{"hl", l.Language},
+		{"gl", l.Country},
+		{"ceid", l.Edition},
+	} {
+		if field.value == "" {
+			return fmt.Errorf("invalid locale: missing %s", field.name)
+		}
+		for _, c := range field.value {
+			if !isLocaleChar(c) {
+				return fmt.Errorf("invalid locale: %s %q contains %q", field.name, field.value, c)
+			}
+		}
+	}
+	return nil
+}
+
+func isLocaleChar(c rune) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == ':'
+}
+
+// query returns the hl, gl and ceid query parameters of the locale
+func (l Locale) query() string {
+	return url.Values{"hl": {l.Language}, "gl": {l.Country}, "ceid": {l.Edition}}.Encode()
+}
+
+// acceptLanguage returns an Accept-Language header value for the locale
+func (l Locale) acceptLanguage() string {
+	base, _, _ := strings.Cut(l.Language, "-")
+	if strings.Contains(l.Language, "-") {
+		return fmt.Sprintf("%s,%s;q=0.9", l.Language, base)
+	}
+	return fmt.Sprintf("%s-%s,%s;q=0.9", l.Language, l.Country, l.Language)
+}
+
+// resolveLocale returns the configured locale, else the one of the source URL, else DefaultLocale
+func resolveLocale(configured *Locale, sourceURL *url.URL) Locale {
+	locale, _ := lookupLocale(configured, sourceURL)
+	return locale
+}
+
+// lookupLocale is resolveLocale, also reporting whether the locale was asked
+// for rather than DefaultLocale
+func lookupLocale(configured *Locale, sourceURL *url.URL) (Locale, bool) {
+	if configured != nil {
+		return *configured, true
+	}
+	if sourceURL != nil {
+		if locale, ok := localeFromQuery(sourceURL.Query()); ok {
+			return locale, true
+		}
+	}
+	return DefaultLocale, false
+}
+
+// requestLocale returns the locale of a decode and the Accept-Language header
+// to send with it. Without an explicit locale the header is left to the
+// browser profiles, so it matches their User-Agent.
+func (d *GoogleDecoder) requestLocale(sourceURL *url.URL) (Locale, string) {
+	locale, explicit := lookupLocale(d.locale, sourceURL)
+	if !explicit && len(d.profiles) > 0 {
+		return locale, ""
+	}
+	return locale, locale.acceptLanguage()
+}
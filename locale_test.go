@@ -0,0 +1,94 @@
+package gnewsdecoder_test
+
+import (
+	"testing"
+
+	gnews "github.com/alainmucyo/google-news-url-decoder"
+)
+
+func TestLocaleFromURL(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		want   gnews.Locale
+		wantOK bool
+	}{
+		{
+			name:   "Full locale",
+			url:    "https://news.google.com/rss/articles/CBMi?hl=fr&gl=FR&ceid=FR:fr",
+			want:   gnews.Locale{Language: "fr", Country: "FR", Edition: "FR:fr"},
+			wantOK: true,
+		},
+		{
+			name:   "Escaped ceid",
+			url:    "https://news.google.com/read/CBMi?hl=en-US&gl=US&ceid=US%3Aen",
+			want:   gnews.Locale{Language: "en-US", Country: "US", Edition: "US:en"},
+			wantOK: true,
+		},
+		{
+			name:   "Only ceid",
+			url:    "https://news.google.com/rss/articles/CBMi?ceid=JP:ja",
+			want:   gnews.Locale{Language: "ja", Country: "JP", Edition: "JP:ja"},
+			wantOK: true,
+		},
+		{
+			name:   "Only hl with region",
+			url:    "https://news.google.com/rss/articles/CBMi?hl=de-DE",
+			want:   gnews.Locale{Language: "de-DE", Country: "DE", Edition: "DE:de"},
+			wantOK: true,
+		},
+		{
+			name:   "No locale",
+			url:    "https://news.google.com/rss/articles/CBMi?oc=5",
+			wantOK: false,
+		},
+		{
+			name:   "Injected characters",
+			url:    `https://news.google.com/rss/articles/CBMi?hl=fr%22,1&gl=FR`,
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := gnews.LocaleFromURL(tt.url)
+			if ok != tt.wantOK {
+				t.Fatalf("LocaleFromURL() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && got != tt.want {
+				t.Errorf("LocaleFromURL() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocale_AppliedToRequests(t *testing.T) {
+	rec := &headerRecorder{}
+	decoder, err := gnews.NewGoogleDecoder(gnews.WithTransport(rec))
+	if err != nil {
+		t.Fatalf("Failed to create GoogleDecoder: %v", err)
+	}
+
+	decoder.Decode("https://news.google.com/rss/articles/CBMitest123?hl=fr&gl=FR&ceid=FR:fr", nil)
+
+	if len(rec.headers) == 0 {
+		t.Fatal("Expected requests to be sent")
+	}
+	for _, h := range rec.headers {
+		if lang := h.Get("Accept-Language"); lang != "fr-FR,fr;q=0.9" {
+			t.Errorf("Accept-Language = %q, want fr-FR,fr;q=0.9", lang)
+		}
+	}
+}
+
+func TestWithLocale_Invalid(t *testing.T) {
+	_, err := gnews.NewGoogleDecoder(gnews.WithLocale(gnews.Locale{Language: `fr"`}))
+	if err == nil {
+		t.Error("Expected error for invalid locale")
+	}
+
+	_, err = gnews.NewGoogleDecoder(gnews.WithLocale(gnews.Locale{Edition: "DE:de"}))
+	if err != nil {
+		t.Errorf("Expected partial locale to be completed, got %v", err)
+	}
+}
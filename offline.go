@@ -0,0 +1,36 @@
+package gnewsdecoder
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrRequiresNetwork is returned in offline-only mode for IDs that only Google can
+// resolve, and by every HTTP request attempted through the decoder's transport
+var ErrRequiresNetwork = errors.New("decoding requires network access")
+
+// WithOfflineOnly guarantees that the decoder makes no network calls. Inline
+// article IDs are decoded from the ID itself, by Decode as well, and opaque
+// AU_yqL IDs fail with ErrRequiresNetwork. The decoder's transport refuses every
+// request, so a code path that still tries to reach the network fails loudly.
+func WithOfflineOnly() DecoderOption {
+	return func(d *GoogleDecoder) {
+		d.offline = true
+	}
+}
+
+// offlineTransport refuses every request
+type offlineTransport struct{}
+
+func (offlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	return nil, fmt.Errorf("%w: offline-only mode refused %s %s", ErrRequiresNetwork, req.Method, req.URL.Redacted())
+}
+
+// requiresNetwork returns the result for an opaque ID in offline-only mode
+func requiresNetwork() DecodeResult {
+	return DecodeResult{Status: false, Message: ErrRequiresNetwork.Error() + " (offline-only mode)", Err: ErrRequiresNetwork}
+}
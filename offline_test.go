@@ -0,0 +1,54 @@
+package gnewsdecoder_test
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	gnews "github.com/alainmucyo/google-news-url-decoder"
+)
+
+func TestWithOfflineOnly(t *testing.T) {
+	var requests atomic.Int32
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		requests.Add(1)
+		return nil, errors.New("unexpected request")
+	})
+
+	decoder, err := gnews.NewGoogleDecoder(gnews.WithOfflineOnly(), gnews.WithTransport(transport))
+	if err != nil {
+		t.Fatalf("Failed to create GoogleDecoder: %v", err)
+	}
+
+	inline, _ := gnews.EncodeArticleURL("https://example.com/story", gnews.EncodeOptions{AMPURL: "https://example.com/story.amp"})
+	opaque := "https://news.google.com/rss/articles/" + opaqueID("AU_yqLabc", "")
+
+	// Inline IDs decode from the ID itself, even with the signed method
+	if result := decoder.Decode(inline, nil); !result.Status || result.DecodedURL != "https://example.com/story" || result.Method != gnews.MethodOffline {
+		t.Errorf("Decode(inline) = %+v", result)
+	}
+
+	for name, result := range map[string]gnews.DecodeResult{
+		"Decode":      decoder.Decode(opaque, nil),
+		"DecodeV3":    decoder.DecodeV3(opaque),
+		"DecodeBatch": decoder.DecodeBatch([]string{inline, opaque})[1],
+	} {
+		if result.Status || !errors.Is(result.Err, gnews.ErrRequiresNetwork) {
+			t.Errorf("%s(opaque) = %+v, want ErrRequiresNetwork", name, result)
+		}
+	}
+
+	// Direct calls into the network code paths are refused by the transport
+	if params := decoder.GetDecodingParams("CBMiabc"); params.Status || !strings.Contains(params.Message, "offline-only mode refused") {
+		t.Errorf("GetDecodingParams() = %+v, want refusal", params)
+	}
+	if result := decoder.DecodeURL("sig", "1700000000", "CBMiabc"); result.Status || !strings.Contains(result.Message, "offline-only mode refused") {
+		t.Errorf("DecodeURL() = %+v, want refusal", result)
+	}
+
+	if n := requests.Load(); n != 0 {
+		t.Errorf("Transport saw %d requests in offline-only mode", n)
+	}
+}
@@ -0,0 +1,290 @@
+package gnewsdecoder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProxyStrategy selects which proxy of a pool serves the next decode
+type ProxyStrategy int
+
+const (
+	// ProxyRoundRobin cycles through the healthy proxies in order
+	ProxyRoundRobin ProxyStrategy = iota
+	// ProxyRandom picks a random healthy proxy
+	ProxyRandom
+	// ProxyLeastRecentlyFailed picks the healthy proxy whose last failure is the oldest
+	ProxyLeastRecentlyFailed
+)
+
+// Default quarantine settings for proxy pools
+const (
+	DefaultProxyMaxFailures = 3
+	DefaultProxyCooldown    = 5 * time.Minute
+)
+
+// ErrAllProxiesQuarantined is returned when every proxy of a pool is quarantined
+var ErrAllProxiesQuarantined = errors.New("all proxies are quarantined")
+
+// ProxyStatus reports the health of a single proxy in a pool
+type ProxyStatus struct {
+	URL              string    `json:"url"`
+	Failures         int       `json:"failures"`
+	Quarantined      bool      `json:"quarantined"`
+	LastFailure      time.Time `json:"last_failure,omitempty"`
+	QuarantinedUntil time.Time `json:"quarantined_until,omitempty"`
+}
+
+type proxyPoolConfig struct {
+	proxies     []string
+	strategy    ProxyStrategy
+	maxFailures int
+	cooldown    time.Duration
+}
+
+// WithProxyPool routes requests through a pool of proxies using the given strategy.
+// Each decode is served by a single proxy, reported in DecodeResult.Proxy.
+// A proxy is quarantined after repeated 429, CAPTCHA or connection errors and
+// returns to the pool after a cooldown (see WithProxyQuarantine).
+func WithProxyPool(proxies []string, strategy ProxyStrategy) DecoderOption {
+	return func(d *GoogleDecoder) {
+		d.poolConfig = &proxyPoolConfig{proxies: proxies, strategy: strategy}
+	}
+}
+
+// WithProxyQuarantine sets how many consecutive failures quarantine a pool proxy
+// and how long it stays out of rotation. It only has an effect together with WithProxyPool.
+func WithProxyQuarantine(maxFailures int, cooldown time.Duration) DecoderOption {
+	return func(d *GoogleDecoder) {
+		d.proxyMaxFailures = maxFailures
+		d.proxyCooldown = cooldown
+	}
+}
+
+// ProxyStats returns the health of each proxy in the pool, or nil if no pool is configured
+func (d *GoogleDecoder) ProxyStats() []ProxyStatus {
+	if d.pool == nil {
+		return nil
+	}
+	return d.pool.stats()
+}
+
+// pooledProxy is a single proxy of a pool along with its failure tracking
+type pooledProxy struct {
+	name             string
+	transport        http.RoundTripper
+	failures         int
+	lastFailure      time.Time
+	quarantinedUntil time.Time
+}
+
+// proxyPool is an http.RoundTripper that spreads requests over several proxies
+type proxyPool struct {
+	mu          sync.Mutex
+	proxies     []*pooledProxy
+	strategy    ProxyStrategy
+	maxFailures int
+	cooldown    time.Duration
+	next        int
+	now         func() time.Time
+}
+
+// newProxyPool builds a transport per proxy on top of clones of the base transport
+func newProxyPool(base http.RoundTripper, cfg proxyPoolConfig) (*proxyPool, error) {
+	if len(cfg.proxies) == 0 {
+		return nil, errors.New("proxy pool is empty")
+	}
+	switch cfg.strategy {
+	case ProxyRoundRobin, ProxyRandom, ProxyLeastRecentlyFailed:
+	default:
+		return nil, fmt.Errorf("unknown proxy strategy: %d", cfg.strategy)
+	}
+	if cfg.maxFailures <= 0 {
+		cfg.maxFailures = DefaultProxyMaxFailures
+	}
+	if cfg.cooldown <= 0 {
+		cfg.cooldown = DefaultProxyCooldown
+	}
+
+	pool := &proxyPool{
+		strategy:    cfg.strategy,
+		maxFailures: cfg.maxFailures,
+		cooldown:    cfg.cooldown,
+		now:         time.Now,
+	}
+
+	for _, proxyURL := range cfg.proxies {
+		cloned, err := cloneTransport(base)
+		if err != nil {
+			return nil, err
+		}
+		transport, err := createTransportWithProxy(cloned, proxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("proxy %s: %w", redactProxyURL(proxyURL), err)
+		}
+		pool.proxies = append(pool.proxies, &pooledProxy{
+			name:      redactProxyURL(proxyURL),
+			transport: transport,
+		})
+	}
+
+	return pool, nil
+}
+
+// RoundTrip sends the request through a proxy of the pool and records its health.
+// Requests of one decode session are pinned to the proxy picked for the first one.
+func (p *proxyPool) RoundTrip(req *http.Request) (*http.Response, error) {
+	session := decodeSessionFrom(req.Context())
+
+	var proxy *pooledProxy
+	if session != nil {
+		proxy = session.proxy
+	}
+	if proxy == nil {
+		var err error
+		proxy, err = p.pick()
+		if err != nil {
+			return nil, err
+		}
+		if session != nil {
+			session.proxy = proxy
+		}
+	}
+
+	resp, err := proxy.transport.RoundTrip(req)
+	switch {
+	case err != nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)):
+		// The caller gave up; this says nothing about the proxy
+	case err != nil:
+		p.recordFailure(proxy)
+	case reachedThroughCaptchaRedirect(req):
+		// The redirect that led here was already counted
+	case isBlockedResponse(req, resp):
+		p.recordFailure(proxy)
+	default:
+		p.recordSuccess(proxy)
+	}
+	return resp, err
+}
+
+// pick selects a healthy proxy according to the pool strategy
+func (p *proxyPool) pick() (*pooledProxy, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	var healthy []*pooledProxy
+	for i := range p.proxies {
+		proxy := p.proxies[(p.next+i)%len(p.proxies)]
+		if !now.Before(proxy.quarantinedUntil) {
+			healthy = append(healthy, proxy)
+		}
+	}
+	if len(healthy) == 0 {
+		return nil, ErrAllProxiesQuarantined
+	}
+
+	var chosen *pooledProxy
+	switch p.strategy {
+	case ProxyRandom:
+		chosen = healthy[rand.IntN(len(healthy))]
+	case ProxyLeastRecentlyFailed:
+		chosen = healthy[0]
+		for _, proxy := range healthy[1:] {
+			if proxy.lastFailure.Before(chosen.lastFailure) {
+				chosen = proxy
+			}
+		}
+	default:
+		chosen = healthy[0]
+	}
+
+	// Advance the cursor past the chosen proxy so ties rotate
+	for i, proxy := range p.proxies {
+		if proxy == chosen {
+			p.next = (i + 1) % len(p.proxies)
+			break
+		}
+	}
+
+	return chosen, nil
+}
+
+func (p *proxyPool) recordFailure(proxy *pooledProxy) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	proxy.failures++
+	proxy.lastFailure = now
+	if proxy.failures >= p.maxFailures {
+		proxy.quarantinedUntil = now.Add(p.cooldown)
+		proxy.failures = 0
+	}
+}
+
+func (p *proxyPool) recordSuccess(proxy *pooledProxy) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	proxy.failures = 0
+}
+
+func (p *proxyPool) stats() []ProxyStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	stats := make([]ProxyStatus, len(p.proxies))
+	for i, proxy := range p.proxies {
+		stats[i] = ProxyStatus{
+			URL:              proxy.name,
+			Failures:         proxy.failures,
+			Quarantined:      now.Before(proxy.quarantinedUntil),
+			LastFailure:      proxy.lastFailure,
+			QuarantinedUntil: proxy.quarantinedUntil,
+		}
+	}
+	return stats
+}
+
+// isBlockedResponse reports whether Google rate limited the request or answered with a CAPTCHA,
+// either a redirect to the CAPTCHA page or the page itself, whatever its status
+func isBlockedResponse(req *http.Request, resp *http.Response) bool {
+	if resp.StatusCode == http.StatusTooManyRequests || isCaptchaRedirect(resp) {
+		return true
+	}
+	finalURL := req.URL
+	if resp.Request != nil {
+		finalURL = resp.Request.URL
+	}
+	return strings.HasPrefix(finalURL.Path, "/sorry/")
+}
+
+// isCaptchaRedirect reports whether resp redirects to the CAPTCHA page
+func isCaptchaRedirect(resp *http.Response) bool {
+	return strings.Contains(resp.Header.Get("Location"), "/sorry/")
+}
+
+// reachedThroughCaptchaRedirect reports whether the client followed a redirect
+// to the CAPTCHA page to send req, so that one block counts once per redirect chain
+func reachedThroughCaptchaRedirect(req *http.Request) bool {
+	return req.Response != nil && isCaptchaRedirect(req.Response)
+}
+
+// redactProxyURL hides the password of a proxy URL for reporting.
+// URLs that don't parse may hold a password anywhere, so none of them is shown.
+func redactProxyURL(proxyURL string) string {
+	parsedURL, err := url.Parse(proxyURL)
+	if err != nil {
+		return "<unparseable proxy URL>"
+	}
+	return parsedURL.Redacted()
+}
@@ -0,0 +1,340 @@
+package gnewsdecoder
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// LinkResult is the outcome for one distinct Google News link found by
+// RewriteHTML, RewriteMarkdown or RewriteText
+type LinkResult struct {
+	// Link is the link as found in the document, HTML entities decoded
+	Link       string `json:"link"`
+	DecodedURL string `json:"decoded_url,omitempty"`
+	// Count is the number of occurrences of the link
+	Count int    `json:"count"`
+	Error string `json:"error,omitempty"`
+}
+
+// linkMatch is a Google News link found in a document
+type linkMatch struct {
+	start, end int64
+	link       string
+	// escape encodes the decoded URL for the context of the match, if needed
+	escape func(string) string
+}
+
+// bareURLRegex matches URLs in text. Trailing punctuation is trimmed by trimURL.
+var bareURLRegex = regexp.MustCompile("(?i)(?:https?://|\\bnews\\.google\\.com/)[^\\s<>\"'`]+")
+
+// RewriteHTML replaces every Google News article link of an HTML document,
+// in href attributes and in text, with the publisher URL. All links are
+// decoded together with a ConcurrentDecoder, each distinct link once. Links
+// that fail to decode are left unchanged and everything else is copied byte
+// for byte. The result of every distinct link is returned in document order.
+func RewriteHTML(ctx context.Context, r io.Reader, w io.Writer, decoder *GoogleDecoder) ([]LinkResult, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input: %v", err)
+	}
+
+	var matches []linkMatch
+	var offset int64
+	skipText := false
+
+	z := html.NewTokenizer(bytes.NewReader(data))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if !errors.Is(z.Err(), io.EOF) {
+				return nil, fmt.Errorf("failed to parse HTML: %v", z.Err())
+			}
+			break
+		}
+
+		raw := z.Raw()
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			matches = append(matches, hrefMatches(raw, offset)...)
+			name, _ := z.TagName()
+			skipText = tt == html.StartTagToken && (string(name) == "script" || string(name) == "style")
+		case html.EndTagToken:
+			skipText = false
+		case html.TextToken:
+			if !skipText {
+				matches = append(matches, bareLinkMatches(raw, offset, true)...)
+			}
+		}
+		offset += int64(len(raw))
+	}
+
+	return rewriteLinks(ctx, data, matches, w, decoder)
+}
+
+// RewriteMarkdown is like RewriteText for Markdown: link targets, autolinks,
+// reference definitions and bare URLs are rewritten, code spans and fenced
+// code blocks are left alone.
+func RewriteMarkdown(ctx context.Context, r io.Reader, w io.Writer, decoder *GoogleDecoder) ([]LinkResult, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input: %v", err)
+	}
+
+	var matches []linkMatch
+	for _, span := range markdownProse(data) {
+		matches = append(matches, bareLinkMatches(data[span[0]:span[1]], int64(span[0]), false)...)
+	}
+	return rewriteLinks(ctx, data, matches, w, decoder)
+}
+
+// RewriteText replaces every Google News article URL of a plain text
+// document with the publisher URL, like RewriteHTML.
+func RewriteText(ctx context.Context, r io.Reader, w io.Writer, decoder *GoogleDecoder) ([]LinkResult, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input: %v", err)
+	}
+	return rewriteLinks(ctx, data, bareLinkMatches(data, 0, false), w, decoder)
+}
+
+// rewriteLinks decodes the distinct links of matches and writes data with the decoded ones replaced
+func rewriteLinks(ctx context.Context, data []byte, matches []linkMatch, w io.Writer, decoder *GoogleDecoder) ([]LinkResult, error) {
+	var links []string
+	index := make(map[string]int)
+	counts := make(map[string]int)
+	for _, m := range matches {
+		if _, ok := index[m.link]; !ok {
+			index[m.link] = len(links)
+			links = append(links, m.link)
+		}
+		counts[m.link]++
+	}
+
+	results := make([]LinkResult, len(links))
+	decoded := NewConcurrentDecoder(decoder, 0).DecodeURLsWithContext(ctx, links, nil)
+	for i, result := range decoded {
+		results[i] = LinkResult{Link: links[i], Count: counts[links[i]]}
+		if result.Status {
+			results[i].DecodedURL = result.DecodedURL
+		} else {
+			results[i].Error = result.Message
+		}
+	}
+
+	var edits []textEdit
+	for _, m := range matches {
+		result := results[index[m.link]]
+		if result.DecodedURL == "" {
+			continue
+		}
+		text := result.DecodedURL
+		if m.escape != nil {
+			text = m.escape(text)
+		}
+		edits = append(edits, textEdit{start: m.start, end: m.end, text: text})
+	}
+
+	if _, err := w.Write(applyEdits(data, edits)); err != nil {
+		return results, fmt.Errorf("failed to write output: %v", err)
+	}
+	return results, nil
+}
+
+// isArticleLink reports whether link is a Google News article URL, possibly behind redirects
+func isArticleLink(link string) bool {
+	c, err := ClassifyURL(link)
+	return err == nil && c.Kind == URLArticle
+}
+
+// bareLinkMatches finds the Google News article URLs of a text located at offset.
+// With escaped set the text is HTML and URLs are entity-decoded before classification.
+func bareLinkMatches(text []byte, offset int64, escaped bool) []linkMatch {
+	var matches []linkMatch
+	for _, loc := range bareURLRegex.FindAllIndex(text, -1) {
+		raw := trimURL(string(text[loc[0]:loc[1]]), emphasisBefore(text, loc[0]))
+		link := raw
+		if escaped {
+			link = html.UnescapeString(raw)
+		}
+		if !isArticleLink(link) {
+			continue
+		}
+		m := linkMatch{start: offset + int64(loc[0]), end: offset + int64(loc[0]+len(raw)), link: link}
+		if escaped {
+			m.escape = html.EscapeString
+		}
+		matches = append(matches, m)
+	}
+	return matches
+}
+
+// trimURL removes the punctuation a URL in prose is usually followed by,
+// keeping closing brackets that have an opening one in the URL. *, _ and ~
+// are valid in URLs and article IDs, so only the markers closing emphasis,
+// the run of markers the URL follows, are removed.
+func trimURL(s, emphasis string) string {
+	for len(s) > 0 {
+		switch last := s[len(s)-1]; {
+		case strings.IndexByte(".,;:!?", last) >= 0:
+		case emphasis != "" && last == emphasis[0]:
+			emphasis = emphasis[1:]
+		case last == ')' && strings.Count(s, "(") < strings.Count(s, ")"):
+		case last == ']' && strings.Count(s, "[") < strings.Count(s, "]"):
+		default:
+			return s
+		}
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+// emphasisBefore returns the run of *, _ or ~ markers ending at text[end]
+func emphasisBefore(text []byte, end int) string {
+	start := end
+	for start > 0 && strings.IndexByte("*_~", text[start-1]) >= 0 && text[start-1] == text[end-1] {
+		start--
+	}
+	return string(text[start:end])
+}
+
+// hrefMatches finds Google News article links in the href attributes of a raw tag located at offset
+func hrefMatches(tag []byte, offset int64) []linkMatch {
+	var matches []linkMatch
+	for _, attr := range scanAttributes(tag) {
+		if attr.name != "href" {
+			continue
+		}
+		link := strings.TrimSpace(html.UnescapeString(string(tag[attr.start:attr.end])))
+		if isArticleLink(link) {
+			matches = append(matches, linkMatch{
+				start:  offset + int64(attr.start),
+				end:    offset + int64(attr.end),
+				link:   link,
+				escape: html.EscapeString,
+			})
+		}
+	}
+	return matches
+}
+
+// rawAttribute is an attribute of a raw tag, with the offsets of its value
+type rawAttribute struct {
+	name       string
+	start, end int
+}
+
+// scanAttributes locates the attribute values of a raw start tag
+func scanAttributes(tag []byte) []rawAttribute {
+	isSpace := func(c byte) bool { return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' }
+
+	var attrs []rawAttribute
+	i := 1
+	for i < len(tag) && !isSpace(tag[i]) && tag[i] != '>' && tag[i] != '/' {
+		i++
+	}
+	for i < len(tag) {
+		for i < len(tag) && (isSpace(tag[i]) || tag[i] == '/') {
+			i++
+		}
+		if i >= len(tag) || tag[i] == '>' {
+			break
+		}
+
+		nameStart := i
+		for i < len(tag) && !isSpace(tag[i]) && tag[i] != '=' && tag[i] != '>' && tag[i] != '/' {
+			i++
+		}
+		attr := rawAttribute{name: strings.ToLower(string(tag[nameStart:i]))}
+		for i < len(tag) && isSpace(tag[i]) {
+			i++
+		}
+		if i >= len(tag) || tag[i] != '=' {
+			continue
+		}
+		i++
+		for i < len(tag) && isSpace(tag[i]) {
+			i++
+		}
+
+		if i < len(tag) && (tag[i] == '"' || tag[i] == '\'') {
+			quote := tag[i]
+			i++
+			attr.start = i
+			for i < len(tag) && tag[i] != quote {
+				i++
+			}
+			attr.end = i
+			i++
+		} else {
+			attr.start = i
+			for i < len(tag) && !isSpace(tag[i]) && tag[i] != '>' {
+				i++
+			}
+			attr.end = i
+		}
+		attrs = append(attrs, attr)
+	}
+	return attrs
+}
+
+// markdownProse returns the byte ranges of a Markdown document outside
+// fenced code blocks and code spans
+func markdownProse(data []byte) [][2]int {
+	var spans [][2]int
+	var fence string
+	for pos := 0; pos < len(data); {
+		end := bytes.IndexByte(data[pos:], '\n')
+		if end < 0 {
+			end = len(data)
+		} else {
+			end += pos + 1
+		}
+		line := data[pos:end]
+
+		trimmed := strings.TrimLeft(string(line), " ")
+		switch {
+		case fence != "":
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			fence = trimmed[:3]
+		default:
+			spans = append(spans, outsideCodeSpans(line, pos)...)
+		}
+		pos = end
+	}
+	return spans
+}
+
+// outsideCodeSpans returns the ranges of a line, located at offset, that are not in `code spans`
+func outsideCodeSpans(line []byte, offset int) [][2]int {
+	var spans [][2]int
+	start := 0
+	for i := 0; i < len(line); {
+		if line[i] != '`' {
+			i++
+			continue
+		}
+		n := 1
+		for i+n < len(line) && line[i+n] == '`' {
+			n++
+		}
+		closing := bytes.Index(line[i+n:], bytes.Repeat([]byte{'`'}, n))
+		if closing < 0 {
+			i += n
+			continue
+		}
+		spans = append(spans, [2]int{offset + start, offset + i})
+		i += n + closing + n
+		start = i
+	}
+	return append(spans, [2]int{offset + start, offset + len(line)})
+}
@@ -0,0 +1,119 @@
+package gnewsdecoder_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	gnews "github.com/alainmucyo/google-news-url-decoder"
+	"github.com/alainmucyo/google-news-url-decoder/gnewstest"
+)
+
+// newRewriteDecoder returns a decoder resolving one opaque article to https://example.com/a?x=1&y=2
+func newRewriteDecoder(t *testing.T) (*gnews.GoogleDecoder, string) {
+	t.Helper()
+	srv := gnewstest.NewServer()
+	t.Cleanup(srv.Close)
+
+	id := opaqueID("AU_yqLrewrite", "")
+	srv.AddArticle(id, "https://example.com/a?x=1&y=2")
+
+	decoder, err := gnews.NewGoogleDecoder(srv.Option())
+	if err != nil {
+		t.Fatalf("Failed to create GoogleDecoder: %v", err)
+	}
+	return decoder, "https://news.google.com/rss/articles/" + id
+}
+
+func TestRewriteHTML(t *testing.T) {
+	decoder, link := newRewriteDecoder(t)
+
+	input := `<p>Read <a class="x" href="` + link + `?oc=5&amp;hl=en">this</a> or ` + link + `.</p>` +
+		`<script>var u = "` + link + `";</script>` +
+		`<a href='https://news.google.com/rss/articles/CBMiunknown'>gone</a>`
+	want := `<p>Read <a class="x" href="https://example.com/a?x=1&amp;y=2">this</a> or https://example.com/a?x=1&amp;y=2.</p>` +
+		`<script>var u = "` + link + `";</script>` +
+		`<a href='https://news.google.com/rss/articles/CBMiunknown'>gone</a>`
+
+	var out bytes.Buffer
+	results, err := gnews.RewriteHTML(context.Background(), strings.NewReader(input), &out, decoder)
+	if err != nil {
+		t.Fatalf("RewriteHTML() error = %v", err)
+	}
+	if out.String() != want {
+		t.Errorf("RewriteHTML() =\n%s\nwant\n%s", out.String(), want)
+	}
+
+	if len(results) != 3 {
+		t.Fatalf("results = %+v, want 3 distinct links", results)
+	}
+	if results[0].Link != link+"?oc=5&hl=en" || results[0].DecodedURL != "https://example.com/a?x=1&y=2" {
+		t.Errorf("results[0] = %+v", results[0])
+	}
+	if results[2].DecodedURL != "" || results[2].Error == "" {
+		t.Errorf("results[2] = %+v, want a decode error", results[2])
+	}
+}
+
+func TestRewriteMarkdown(t *testing.T) {
+	decoder, link := newRewriteDecoder(t)
+
+	input := "See [the story](" + link + " \"Title\") and <" + link + ">, `" + link + "`.\n\n" +
+		"```\n" + link + "\n```\n" +
+		"[ref]: " + link + "\n"
+	want := "See [the story](https://example.com/a?x=1&y=2 \"Title\") and <https://example.com/a?x=1&y=2>, `" + link + "`.\n\n" +
+		"```\n" + link + "\n```\n" +
+		"[ref]: https://example.com/a?x=1&y=2\n"
+
+	var out bytes.Buffer
+	results, err := gnews.RewriteMarkdown(context.Background(), strings.NewReader(input), &out, decoder)
+	if err != nil {
+		t.Fatalf("RewriteMarkdown() error = %v", err)
+	}
+	if out.String() != want {
+		t.Errorf("RewriteMarkdown() =\n%s\nwant\n%s", out.String(), want)
+	}
+	if len(results) != 1 || results[0].Count != 3 {
+		t.Errorf("results = %+v, want one link found 3 times", results)
+	}
+}
+
+func TestRewriteText(t *testing.T) {
+	decoder, link := newRewriteDecoder(t)
+
+	input := "Breaking (" + link + "), also news.google.com/rss/articles/" + strings.TrimPrefix(link, "https://news.google.com/rss/articles/") + "! Not https://example.org/news."
+	want := "Breaking (https://example.com/a?x=1&y=2), also https://example.com/a?x=1&y=2! Not https://example.org/news."
+
+	var out bytes.Buffer
+	if _, err := gnews.RewriteText(context.Background(), strings.NewReader(input), &out, decoder); err != nil {
+		t.Fatalf("RewriteText() error = %v", err)
+	}
+	if out.String() != want {
+		t.Errorf("RewriteText() =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestRewriteText_TrailingUnderscore(t *testing.T) {
+	decoder, err := gnews.NewGoogleDecoder(gnews.WithOfflineOnly())
+	if err != nil {
+		t.Fatalf("Failed to create GoogleDecoder: %v", err)
+	}
+
+	// The ID ends with the base64url character _
+	link := "https://news.google.com/rss/articles/CBMiHWh0dHBzOi8vZXhhbXBsZS5jb20vc3RvcnktMTA_"
+	input := "See " + link + ", **" + link + "** and _" + link + "_."
+	want := "See https://example.com/story-10?, **https://example.com/story-10?** and _https://example.com/story-10?_."
+
+	var out bytes.Buffer
+	results, err := gnews.RewriteText(context.Background(), strings.NewReader(input), &out, decoder)
+	if err != nil {
+		t.Fatalf("RewriteText() error = %v", err)
+	}
+	if out.String() != want {
+		t.Errorf("RewriteText() =\n%s\nwant\n%s", out.String(), want)
+	}
+	if len(results) != 1 || results[0].Count != 3 {
+		t.Errorf("results = %+v, want one link found 3 times", results)
+	}
+}
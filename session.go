@@ -0,0 +1,30 @@
+package gnewsdecoder
+
+import "context"
+
+type decodeSessionKey struct{}
+
+// decodeSession carries per-decode state through request contexts, so that
+// all requests of one decode share the same proxy and browser profile.
+type decodeSession struct {
+	proxy   *pooledProxy
+	profile *BrowserProfile
+}
+
+func withDecodeSession(ctx context.Context) (context.Context, *decodeSession) {
+	session := &decodeSession{}
+	return context.WithValue(ctx, decodeSessionKey{}, session), session
+}
+
+func decodeSessionFrom(ctx context.Context) *decodeSession {
+	session, _ := ctx.Value(decodeSessionKey{}).(*decodeSession)
+	return session
+}
+
+// proxyName returns the redacted URL of the proxy that served the session
+func (s *decodeSession) proxyName() string {
+	if s.proxy == nil {
+		return ""
+	}
+	return s.proxy.name
+}
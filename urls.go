@@ -0,0 +1,189 @@
+package gnewsdecoder
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// URLKind is the kind of URL recognized by ClassifyURL
+type URLKind int
+
+const (
+	// URLUnknown is not a Google News URL
+	URLUnknown URLKind = iota
+	// URLArticle is a Google News article URL carrying an article ID
+	URLArticle
+	// URLStory is a Google News full coverage page, which has no single publisher URL
+	URLStory
+)
+
+func (k URLKind) String() string {
+	switch k {
+	case URLArticle:
+		return "article"
+	case URLStory:
+		return "story"
+	default:
+		return "unknown"
+	}
+}
+
+// maxRedirectDepth bounds how many redirect wrappers ClassifyURL removes
+const maxRedirectDepth = 5
+
+// errStoryURL is returned when decoding a full coverage page
+var errStoryURL = errors.New("Google News story URLs point to a full coverage page, not a single article")
+
+// ClassifiedURL is a Google News URL normalized for decoding
+type ClassifiedURL struct {
+	Kind URLKind
+	// ID is the article or story ID
+	ID string
+	// URL is the normalized https://news.google.com URL. The query is kept, so
+	// the hl, gl and ceid locale parameters still apply.
+	URL string
+	// Unwrapped lists the redirect wrappers that were removed, outermost first
+	Unwrapped []string
+}
+
+// ClassifyURL recognizes the Google News URL shapes seen in the wild and
+// normalizes them for decoding. It accepts
+//   - /articles/, /rss/articles/, /__i/rss/rd/articles/ and /read/ paths, with or without trailing slashes
+//   - /stories/ full coverage pages
+//   - google.com/url?q=...|url=... redirects, e.g. from Google Alerts
+//   - consent.google.com/?continue=... wrappers
+//   - uppercase hosts, http:// and URLs without a scheme
+//
+// URLs that are not Google News URLs are reported as an error.
+func ClassifyURL(rawURL string) (ClassifiedURL, error) {
+	current, unwrapped, err := unwrapRedirects(strings.TrimSpace(rawURL))
+	if err != nil {
+		return ClassifiedURL{}, err
+	}
+	c := ClassifiedURL{Unwrapped: unwrapped}
+
+	parsedURL, _ := parseLooseURL(current)
+	if strings.ToLower(parsedURL.Hostname()) != "news.google.com" {
+		return ClassifiedURL{}, errors.New("invalid Google News URL format")
+	}
+
+	path := splitPath(parsedURL.Path)
+	if len(path) < 2 {
+		return ClassifiedURL{}, errors.New("invalid Google News URL format")
+	}
+
+	c.ID = path[len(path)-1]
+	switch path[len(path)-2] {
+	case "articles", "read":
+		c.Kind = URLArticle
+		c.URL = "https://news.google.com/rss/articles/" + c.ID
+	case "stories":
+		c.Kind = URLStory
+		c.URL = "https://news.google.com/stories/" + c.ID
+	default:
+		return ClassifiedURL{}, errors.New("invalid Google News URL format")
+	}
+	if parsedURL.RawQuery != "" {
+		c.URL += "?" + parsedURL.RawQuery
+	}
+	return c, nil
+}
+
+// UnwrapRedirects removes google.com/url and consent.google.com wrappers from a URL,
+// following nested ones, and returns the target. Other URLs are returned unchanged.
+func UnwrapRedirects(rawURL string) string {
+	target, _, err := unwrapRedirects(strings.TrimSpace(rawURL))
+	if err != nil {
+		return rawURL
+	}
+	return target
+}
+
+// unwrapRedirects returns the innermost target of rawURL and the wrappers removed, outermost first
+func unwrapRedirects(rawURL string) (string, []string, error) {
+	var unwrapped []string
+
+	current := rawURL
+	for depth := 0; ; depth++ {
+		parsedURL, err := parseLooseURL(current)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to parse URL: %v", err)
+		}
+
+		target, ok := redirectTarget(parsedURL)
+		if !ok {
+			return current, unwrapped, nil
+		}
+		if depth == maxRedirectDepth {
+			return "", nil, errors.New("too many nested redirect URLs")
+		}
+		unwrapped = append(unwrapped, current)
+		current = target
+	}
+}
+
+// parseLooseURL parses an absolute URL, assuming https when the scheme is missing
+func parseLooseURL(rawURL string) (*url.URL, error) {
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if scheme := strings.ToLower(parsedURL.Scheme); scheme != "http" && scheme != "https" {
+		return nil, fmt.Errorf("unsupported scheme %q", parsedURL.Scheme)
+	}
+	return parsedURL, nil
+}
+
+// redirectTarget returns the URL wrapped by a google.com/url redirect or a consent page
+func redirectTarget(parsedURL *url.URL) (string, bool) {
+	host := strings.ToLower(parsedURL.Hostname())
+	query := parsedURL.Query()
+
+	var target string
+	switch {
+	case strings.HasPrefix(host, "consent.google."):
+		target = query.Get("continue")
+	case isGoogleHost(host) && strings.TrimRight(parsedURL.Path, "/") == "/url":
+		target = query.Get("url")
+		if target == "" {
+			target = query.Get("q")
+		}
+	}
+
+	if target == "" {
+		return "", false
+	}
+	if _, err := parseLooseURL(target); err != nil {
+		return "", false
+	}
+	return target, true
+}
+
+// isGoogleHost reports whether host is google.<tld> or www.google.<tld>
+func isGoogleHost(host string) bool {
+	tld, ok := strings.CutPrefix(strings.TrimPrefix(host, "www."), "google.")
+	return ok && tld != "" && strings.Count(tld, ".") <= 1
+}
+
+// classifyArticle classifies sourceURL and requires an article URL.
+// It returns the normalized URL parsed, for locale lookups.
+func classifyArticle(sourceURL string) (ClassifiedURL, *url.URL, error) {
+	c, err := ClassifyURL(sourceURL)
+	if err != nil {
+		return ClassifiedURL{}, nil, err
+	}
+	if c.Kind == URLStory {
+		return ClassifiedURL{}, nil, errStoryURL
+	}
+
+	parsedURL, err := url.Parse(c.URL)
+	if err != nil {
+		return ClassifiedURL{}, nil, fmt.Errorf("failed to parse URL: %v", err)
+	}
+	return c, parsedURL, nil
+}
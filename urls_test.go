@@ -0,0 +1,96 @@
+package gnewsdecoder_test
+
+import (
+	"net/url"
+	"testing"
+
+	gnews "github.com/alainmucyo/google-news-url-decoder"
+)
+
+func TestClassifyURL(t *testing.T) {
+	const id = "CBMiLmh0dHBzOi8vd3d3LmJiYy5jb20vbmV3cy9hcnRpY2xlcy9jampqbnhkdjE4OG_SATJodHRwczovL3d3dy5iYmMuY29tL25ld3MvYXJ0aWNsZXMvY2pqam54ZHYxODhvLmFtcA"
+	article := "https://news.google.com/rss/articles/" + id
+
+	tests := []struct {
+		name    string
+		input   string
+		kind    gnews.URLKind
+		wantURL string
+		wantErr bool
+	}{
+		{name: "rss articles", input: article + "?oc=5", kind: gnews.URLArticle, wantURL: article + "?oc=5"},
+		{name: "articles", input: "https://news.google.com/articles/" + id, kind: gnews.URLArticle, wantURL: article},
+		{name: "read", input: "https://news.google.com/read/" + id + "?hl=fr&gl=FR&ceid=FR:fr", kind: gnews.URLArticle, wantURL: article + "?hl=fr&gl=FR&ceid=FR:fr"},
+		{name: "rss redirect", input: "https://news.google.com/__i/rss/rd/articles/" + id + "?oc=5", kind: gnews.URLArticle, wantURL: article + "?oc=5"},
+		{name: "trailing slashes", input: "https://news.google.com/rss/articles/" + id + "//", kind: gnews.URLArticle, wantURL: article},
+		{name: "uppercase host", input: "https://NEWS.Google.COM/rss/articles/" + id, kind: gnews.URLArticle, wantURL: article},
+		{name: "http", input: "http://news.google.com/rss/articles/" + id, kind: gnews.URLArticle, wantURL: article},
+		{name: "no scheme", input: "news.google.com/rss/articles/" + id, kind: gnews.URLArticle, wantURL: article},
+		{name: "port", input: "https://news.google.com:443/rss/articles/" + id, kind: gnews.URLArticle, wantURL: article},
+		{name: "surrounding space", input: "  " + article + "\n", kind: gnews.URLArticle, wantURL: article},
+		{
+			name:    "stories",
+			input:   "https://news.google.com/stories/CAAqNggKIjBDQklTSGpvSmMzUnZjbmt0TXpZd1NoRUtEd2pXdGZiR0NSSGF0cVc3MEl5ZEVTZ0FQAQ?hl=en-US",
+			kind:    gnews.URLStory,
+			wantURL: "https://news.google.com/stories/CAAqNggKIjBDQklTSGpvSmMzUnZjbmt0TXpZd1NoRUtEd2pXdGZiR0NSSGF0cVc3MEl5ZEVTZ0FQAQ?hl=en-US",
+		},
+		{
+			name:    "alerts url redirect",
+			input:   "https://www.google.com/url?rct=j&sa=t&url=" + url.QueryEscape(article) + "&ct=ga&cd=CAEYACoUMTY&usg=AOvVaw",
+			kind:    gnews.URLArticle,
+			wantURL: article,
+		},
+		{name: "q redirect", input: "https://google.com/url?q=" + url.QueryEscape(article) + "&sa=D", kind: gnews.URLArticle, wantURL: article},
+		{name: "country redirect", input: "https://www.google.co.uk/url?q=" + url.QueryEscape(article), kind: gnews.URLArticle, wantURL: article},
+		{name: "consent", input: "https://consent.google.com/?continue=" + url.QueryEscape(article+"?oc=5") + "&gl=DE", kind: gnews.URLArticle, wantURL: article + "?oc=5"},
+		{
+			name:    "consent wrapping redirect",
+			input:   "https://consent.google.com/ml?continue=" + url.QueryEscape("https://www.google.com/url?url="+url.QueryEscape(article)),
+			kind:    gnews.URLArticle,
+			wantURL: article,
+		},
+		{name: "publisher URL", input: "https://www.bbc.com/news/articles/cjjjnxdv188o", wantErr: true},
+		{name: "redirect to publisher", input: "https://www.google.com/url?url=https%3A%2F%2Fexample.com%2Fstory", wantErr: true},
+		{name: "google news home", input: "https://news.google.com/", wantErr: true},
+		{name: "topics", input: "https://news.google.com/topics/CAAqJggKIiBDQkFTRWdvSUwyMHZNRGx1YlY4U0FtVnVHZ0pWVXlnQVAB", wantErr: true},
+		{name: "lookalike host", input: "https://news.google.com.evil.example/rss/articles/" + id, wantErr: true},
+		{name: "ftp", input: "ftp://news.google.com/rss/articles/" + id, wantErr: true},
+		{name: "empty", input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := gnews.ClassifyURL(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ClassifyURL() = %+v, want error", c)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ClassifyURL() error = %v", err)
+			}
+			if c.Kind != tt.kind || c.URL != tt.wantURL {
+				t.Errorf("ClassifyURL() = %v %s, want %v %s", c.Kind, c.URL, tt.kind, tt.wantURL)
+			}
+			if c.Kind == gnews.URLArticle && c.ID != id {
+				t.Errorf("ID = %s", c.ID)
+			}
+		})
+	}
+}
+
+func TestClassifyURL_AppliedToDecoders(t *testing.T) {
+	sourceURL := "https://www.google.com/url?rct=j&url=" + url.QueryEscape("HTTP://NEWS.GOOGLE.COM/__i/rss/rd/articles/CBMiGWh0dHBzOi8vZXhhbXBsZS5jb20vc3Rvcnk/")
+
+	if decoded := gnews.DecoderV1(sourceURL); decoded != "https://example.com/story" {
+		t.Errorf("DecoderV1() = %q", decoded)
+	}
+	if result := gnews.DecoderV3(sourceURL); !result.Status || result.DecodedURL != "https://example.com/story" {
+		t.Errorf("DecoderV3() = %+v", result)
+	}
+
+	if result := gnews.DecoderV3("https://news.google.com/stories/CAAqNggKIjBDQklTSGpvSmMzUnZjbmt0TXpZd1NoRUtEd2pXdGZiR0NSSGF0cVc3MEl5ZEVTZ0FQAQ"); result.Status {
+		t.Errorf("DecoderV3(story) = %+v, want failure", result)
+	}
+}
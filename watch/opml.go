@@ -0,0 +1,160 @@
+package watch
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	gnews "github.com/alainmucyo/google-news-url-decoder"
+)
+
+// opmlDocument is the part of an OPML subscription list ReadOPML uses
+type opmlDocument struct {
+	Body struct {
+		Outlines []opmlOutline `xml:"outline"`
+	} `xml:"body"`
+}
+
+type opmlOutline struct {
+	Text     string        `xml:"text,attr"`
+	Title    string        `xml:"title,attr"`
+	XMLURL   string        `xml:"xmlUrl,attr"`
+	Category string        `xml:"category,attr"`
+	Outlines []opmlOutline `xml:"outline"`
+}
+
+// ReadOPML reads the feeds of an OPML subscription list. Nested groups are
+// flattened: the titles of the enclosing groups and the entries of the
+// category attribute become the feed's tags. A feed listed in several groups
+// is returned once with the tags of all of them.
+func ReadOPML(r io.Reader) ([]Feed, error) {
+	var doc opmlDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse OPML: %v", err)
+	}
+
+	var feeds []Feed
+	index := make(map[string]int)
+	var walk func(outlines []opmlOutline, groups []string)
+	walk = func(outlines []opmlOutline, groups []string) {
+		for _, o := range outlines {
+			title := strings.TrimSpace(o.Title)
+			if title == "" {
+				title = strings.TrimSpace(o.Text)
+			}
+
+			feedURL := strings.TrimSpace(o.XMLURL)
+			if feedURL == "" {
+				group := groups
+				if title != "" {
+					group = append(groups[:len(groups):len(groups)], title)
+				}
+				walk(o.Outlines, group)
+				continue
+			}
+
+			tags := append(append([]string(nil), groups...), splitCategory(o.Category)...)
+			if i, ok := index[feedURL]; ok {
+				feeds[i].Tags = mergeTags(feeds[i].Tags, tags)
+				continue
+			}
+			index[feedURL] = len(feeds)
+			feeds = append(feeds, Feed{URL: feedURL, Title: title, Tags: mergeTags(nil, tags)})
+		}
+	}
+	walk(doc.Body.Outlines, nil)
+
+	if len(feeds) == 0 {
+		return nil, errors.New("no feeds in OPML")
+	}
+	return feeds, nil
+}
+
+// splitCategory splits an OPML category attribute, a comma-separated list of
+// slash-delimited paths such as "/News/Tech,Go"
+func splitCategory(category string) []string {
+	var tags []string
+	for _, c := range strings.Split(category, ",") {
+		if c = strings.Trim(strings.TrimSpace(c), "/"); c != "" {
+			tags = append(tags, c)
+		}
+	}
+	return tags
+}
+
+// mergeTags appends the tags not already in dst
+func mergeTags(dst, tags []string) []string {
+	for _, tag := range tags {
+		found := false
+		for _, t := range dst {
+			if t == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst = append(dst, tag)
+		}
+	}
+	return dst
+}
+
+// WriteOPML writes feeds as a flat OPML 2.0 subscription list, with tags in
+// the category attribute. The edition of every feed and, when store is not
+// nil, its state are added as gnews: attributes.
+func WriteOPML(w io.Writer, title string, feeds []Feed, store Store) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	fmt.Fprintf(bw, "<opml version=\"2.0\" xmlns:gnews=\"%s\">\n", gnews.FeedNamespace)
+	fmt.Fprintf(bw, "  <head>\n    <title>%s</title>\n    <dateCreated>%s</dateCreated>\n  </head>\n",
+		escapeAttr(title), time.Now().UTC().Format(time.RFC1123Z))
+	fmt.Fprintf(bw, "  <body>\n")
+
+	for _, feed := range feeds {
+		text := feed.Title
+		if text == "" {
+			text = feed.URL
+		}
+		attrs := [][2]string{{"type", "rss"}, {"text", text}}
+		if feed.Title != "" {
+			attrs = append(attrs, [2]string{"title", feed.Title})
+		}
+		attrs = append(attrs, [2]string{"xmlUrl", feed.URL})
+		if len(feed.Tags) > 0 {
+			attrs = append(attrs, [2]string{"category", strings.Join(feed.Tags, ",")})
+		}
+		if locale, ok := gnews.LocaleFromURL(feed.URL); ok {
+			attrs = append(attrs, [2]string{"gnews:edition", locale.Edition})
+		}
+		if store != nil {
+			fs := store.Feed(feed.URL)
+			if !fs.LastPolled.IsZero() {
+				attrs = append(attrs, [2]string{"gnews:lastPolled", fs.LastPolled.Format(time.RFC3339)})
+			}
+			attrs = append(attrs, [2]string{"gnews:articles", strconv.Itoa(fs.Articles)})
+			if fs.LastError != "" {
+				attrs = append(attrs, [2]string{"gnews:lastError", fs.LastError})
+			}
+		}
+
+		fmt.Fprintf(bw, "    <outline")
+		for _, attr := range attrs {
+			fmt.Fprintf(bw, " %s=\"%s\"", attr[0], escapeAttr(attr[1]))
+		}
+		fmt.Fprintf(bw, "/>\n")
+	}
+
+	fmt.Fprintf(bw, "  </body>\n</opml>\n")
+	return bw.Flush()
+}
+
+func escapeAttr(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,72 @@
+package watch_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alainmucyo/google-news-url-decoder/watch"
+)
+
+const subscriptions = `<?xml version="1.0" encoding="UTF-8"?>
+<opml version="2.0">
+  <head><title>Newsroom</title></head>
+  <body>
+    <outline text="Tech">
+      <outline text="Go" title="Go news" type="rss" xmlUrl="https://news.google.com/rss/search?q=golang&amp;hl=en-GB&amp;gl=GB&amp;ceid=GB:en" category="/Languages/Go,Weekly"/>
+      <outline text="Europe">
+        <outline text="EU tech" type="rss" xmlUrl="https://news.google.com/rss/headlines/section/topic/TECHNOLOGY?ceid=FR:fr"/>
+      </outline>
+    </outline>
+    <outline text="Languages">
+      <outline text="Go" type="rss" xmlUrl="https://news.google.com/rss/search?q=golang&amp;hl=en-GB&amp;gl=GB&amp;ceid=GB:en"/>
+    </outline>
+    <outline text="Top" type="rss" xmlUrl="https://news.google.com/rss"/>
+  </body>
+</opml>`
+
+func TestReadOPML(t *testing.T) {
+	feeds, err := watch.ReadOPML(strings.NewReader(subscriptions))
+	if err != nil {
+		t.Fatalf("ReadOPML() error = %v", err)
+	}
+
+	want := []watch.Feed{
+		{URL: "https://news.google.com/rss/search?q=golang&hl=en-GB&gl=GB&ceid=GB:en", Title: "Go news", Tags: []string{"Tech", "Languages/Go", "Weekly", "Languages"}},
+		{URL: "https://news.google.com/rss/headlines/section/topic/TECHNOLOGY?ceid=FR:fr", Title: "EU tech", Tags: []string{"Tech", "Europe"}},
+		{URL: "https://news.google.com/rss", Title: "Top"},
+	}
+	if !reflect.DeepEqual(feeds, want) {
+		t.Errorf("ReadOPML() = %+v, want %+v", feeds, want)
+	}
+
+	if _, err := watch.ReadOPML(strings.NewReader(`<opml><body><outline text="Empty"/></body></opml>`)); err == nil {
+		t.Error("ReadOPML() of a list without feeds succeeded, want error")
+	}
+}
+
+func TestWriteOPML_RoundTrip(t *testing.T) {
+	feeds, _ := watch.ReadOPML(strings.NewReader(subscriptions))
+
+	store := watch.NewMemoryStore()
+	store.SetFeed(feeds[0].URL, watch.FeedState{Articles: 12, LastError: `status: 429 & "retry"`})
+
+	var out bytes.Buffer
+	if err := watch.WriteOPML(&out, "Newsroom", feeds, store); err != nil {
+		t.Fatalf("WriteOPML() error = %v", err)
+	}
+	for _, want := range []string{`gnews:edition="GB:en"`, `gnews:articles="12"`, `gnews:lastError="status: 429 &amp; &#34;retry&#34;"`, `category="Tech,Languages/Go,Weekly,Languages"`} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("WriteOPML() output lacks %s:\n%s", want, out.String())
+		}
+	}
+
+	again, err := watch.ReadOPML(&out)
+	if err != nil {
+		t.Fatalf("ReadOPML() of exported list error = %v", err)
+	}
+	if !reflect.DeepEqual(again, feeds) {
+		t.Errorf("round trip = %+v, want %+v", again, feeds)
+	}
+}
@@ -0,0 +1,201 @@
+package watch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Validators are the cache validators of a feed response, sent back on the
+// next poll so unchanged feeds answer 304 Not Modified
+type Validators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// FeedState is what a Store keeps about a feed
+type FeedState struct {
+	Validators
+	LastPolled time.Time `json:"last_polled,omitempty"`
+	// Articles counts the articles emitted from the feed
+	Articles  int    `json:"articles,omitempty"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// DefaultSeenTTL is how long a Store remembers an article ID after it was
+// last listed in a feed. Google News feeds only list recent articles, so IDs
+// gone for that long will not come back.
+const DefaultSeenTTL = 30 * 24 * time.Hour
+
+// Store remembers the article IDs already emitted and the state of every feed.
+// Implementations must be safe for concurrent use.
+type Store interface {
+	Seen(id string) bool
+	// MarkSeen records article IDs as emitted, or as still listed in a feed
+	MarkSeen(ids ...string) error
+	Feed(feedURL string) FeedState
+	SetFeed(feedURL string, fs FeedState) error
+	// Save persists the changes made since the last call.
+	// The Watcher calls it once per poll.
+	Save() error
+}
+
+// state is the content of a store
+type state struct {
+	// Seen maps article IDs to the time they were last emitted or listed
+	Seen  map[string]time.Time `json:"seen"`
+	Feeds map[string]FeedState `json:"feeds"`
+}
+
+// StoreOption configures a MemoryStore
+type StoreOption func(*MemoryStore)
+
+// WithSeenTTL sets how long article IDs are remembered after they were last
+// listed in a feed, DefaultSeenTTL by default
+func WithSeenTTL(ttl time.Duration) StoreOption {
+	return func(s *MemoryStore) {
+		if ttl > 0 {
+			s.seenTTL = ttl
+		}
+	}
+}
+
+// MemoryStore is a Store that lives as long as the process
+type MemoryStore struct {
+	mu      sync.Mutex
+	state   state
+	seenTTL time.Duration
+	dirty   bool
+	// save persists the state on Save, if set
+	save func(state) error
+}
+
+// NewMemoryStore returns an empty in-memory store
+func NewMemoryStore(opts ...StoreOption) *MemoryStore {
+	s := &MemoryStore{
+		state:   state{Seen: make(map[string]time.Time), Feeds: make(map[string]FeedState)},
+		seenTTL: DefaultSeenTTL,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Seen reports whether the article ID was already emitted
+func (s *MemoryStore) Seen(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.state.Seen[id]
+	return ok
+}
+
+// MarkSeen records article IDs as emitted, or refreshes them when still listed
+func (s *MemoryStore) MarkSeen(ids ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now().UTC()
+	for _, id := range ids {
+		s.state.Seen[id] = now
+	}
+	s.dirty = s.dirty || len(ids) > 0
+	return nil
+}
+
+// Feed returns the state of a feed
+func (s *MemoryStore) Feed(feedURL string) FeedState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state.Feeds[feedURL]
+}
+
+// SetFeed records the state of a feed
+func (s *MemoryStore) SetFeed(feedURL string, fs FeedState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.Feeds[feedURL] = fs
+	s.dirty = true
+	return nil
+}
+
+// Save forgets the article IDs not listed for longer than the TTL and
+// persists the state if it changed
+func (s *MemoryStore) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := time.Now().Add(-s.seenTTL)
+	for id, seen := range s.state.Seen {
+		if seen.Before(cutoff) {
+			delete(s.state.Seen, id)
+			s.dirty = true
+		}
+	}
+
+	if !s.dirty || s.save == nil {
+		return nil
+	}
+	if err := s.save(s.state); err != nil {
+		return err
+	}
+	s.dirty = false
+	return nil
+}
+
+// OpenFileStore returns a store persisted as JSON at path, loading it if the
+// file exists. Save rewrites the file atomically.
+func OpenFileStore(path string, opts ...StoreOption) (*MemoryStore, error) {
+	s := NewMemoryStore(opts...)
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("failed to read store: %v", err)
+	default:
+		if err := json.Unmarshal(data, &s.state); err != nil {
+			return nil, fmt.Errorf("failed to parse store %s: %v", path, err)
+		}
+		if s.state.Seen == nil {
+			s.state.Seen = make(map[string]time.Time)
+		}
+		if s.state.Feeds == nil {
+			s.state.Feeds = make(map[string]FeedState)
+		}
+	}
+
+	s.save = func(st state) error {
+		return writeFileAtomic(path, st)
+	}
+	return s, nil
+}
+
+// writeFileAtomic writes v as JSON to a temporary file and renames it over path
+func writeFileAtomic(path string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save store: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save store: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save store: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to save store: %v", err)
+	}
+	return nil
+}
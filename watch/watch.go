@@ -0,0 +1,429 @@
+// Package watch polls Google News RSS feeds and reports the articles that
+// appear in them, with their publisher URLs.
+//
+// A Watcher remembers the article IDs it has emitted in a Store, so only new
+// items are decoded, and sends the validators of the last response of every
+// feed so unchanged feeds answer 304 Not Modified:
+//
+//	store, _ := watch.OpenFileStore("watch-state.json")
+//	w := watch.New(decoder, store, watch.WithInterval(5*time.Minute))
+//	err := w.Run(ctx, feeds, func(a watch.Article) error {
+//		return json.NewEncoder(os.Stdout).Encode(a)
+//	})
+package watch
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	gnews "github.com/alainmucyo/google-news-url-decoder"
+)
+
+// DefaultInterval is the time between two polls of a feed
+const DefaultInterval = 5 * time.Minute
+
+// DefaultJitter is the fraction of the interval poll times are spread by
+const DefaultJitter = 0.1
+
+// Feed is a feed to poll
+type Feed struct {
+	URL   string `json:"url"`
+	Title string `json:"title,omitempty"`
+	// Tags are copied to every article of the feed, e.g. OPML groups and categories
+	Tags []string `json:"tags,omitempty"`
+}
+
+// Categories returns the title and tags of the feed, without duplicates,
+// as categories for its items
+func (f Feed) Categories() []string {
+	var categories []string
+	seen := make(map[string]bool)
+	for _, c := range append([]string{f.Title}, f.Tags...) {
+		if c = strings.TrimSpace(c); c != "" && !seen[c] {
+			seen[c] = true
+			categories = append(categories, c)
+		}
+	}
+	return categories
+}
+
+// Article is a newly seen feed item with its publisher URL
+type Article struct {
+	Feed      string    `json:"feed"`
+	FeedTitle string    `json:"feed_title,omitempty"`
+	Tags      []string  `json:"tags,omitempty"`
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	Link      string    `json:"link"`
+	URL       string    `json:"url"`
+	Source    string    `json:"source,omitempty"`
+	Published time.Time `json:"published"`
+}
+
+// FeedItem returns the article as a feed item, e.g. to write it with gnews.WriteFeed
+func (a Article) FeedItem() gnews.FeedItem {
+	return gnews.FeedItem{
+		Title:      a.Title,
+		Link:       a.Link,
+		DecodedURL: a.URL,
+		GUID:       a.ID,
+		Published:  a.Published,
+		Source:     a.Source,
+		Categories: Feed{Title: a.FeedTitle, Tags: a.Tags}.Categories(),
+	}
+}
+
+// Watcher polls feeds and emits the articles it has not seen before
+type Watcher struct {
+	decoder  *gnews.GoogleDecoder
+	store    Store
+	interval time.Duration
+	jitter   float64
+	onError  func(Feed, error)
+
+	mu sync.Mutex
+	// pending holds the IDs being decoded, so feeds polled at the same time do not emit them twice
+	pending map[string]bool
+}
+
+// Option configures a Watcher
+type Option func(*Watcher)
+
+// WithInterval sets the time between two polls of a feed, DefaultInterval by default
+func WithInterval(interval time.Duration) Option {
+	return func(w *Watcher) {
+		if interval > 0 {
+			w.interval = interval
+		}
+	}
+}
+
+// WithJitter spreads every wait between polls by up to the given fraction of
+// the interval, DefaultJitter by default. Zero polls on a fixed schedule.
+func WithJitter(fraction float64) Option {
+	return func(w *Watcher) {
+		if fraction >= 0 && fraction < 1 {
+			w.jitter = fraction
+		}
+	}
+}
+
+// WithErrorHandler is called when a feed fails to poll or an item fails to
+// decode. Failed items stay unseen and are retried on the next poll.
+func WithErrorHandler(handler func(Feed, error)) Option {
+	return func(w *Watcher) {
+		w.onError = handler
+	}
+}
+
+// New returns a Watcher fetching feeds and decoding links with the decoder
+func New(decoder *gnews.GoogleDecoder, store Store, opts ...Option) *Watcher {
+	w := &Watcher{
+		decoder:  decoder,
+		store:    store,
+		interval: DefaultInterval,
+		jitter:   DefaultJitter,
+		onError:  func(Feed, error) {},
+		pending:  make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Poll fetches a feed once and returns the items not seen before, decoded.
+// They are marked as seen, and the store saved, before Poll returns.
+func (w *Watcher) Poll(ctx context.Context, feed Feed) ([]Article, error) {
+	var articles []Article
+	err := w.poll(ctx, feed, func(a Article) bool {
+		articles = append(articles, a)
+		return true
+	})
+	return articles, err
+}
+
+// poll fetches a feed once and passes the items not seen before, decoded, to
+// emit until it returns false. Only the items emit accepted are marked as
+// seen. The feed state is updated and the store saved once per poll.
+func (w *Watcher) poll(ctx context.Context, feed Feed, emit func(Article) bool) error {
+	fs := w.store.Feed(feed.URL)
+	fs.LastPolled = time.Now().UTC()
+
+	emitted, err := w.pollFeed(ctx, feed, &fs, emit)
+	fs.Articles += emitted
+	if err != nil {
+		fs.LastError = err.Error()
+	}
+	if serr := w.store.SetFeed(feed.URL, fs); err == nil {
+		err = serr
+	}
+	if serr := w.store.Save(); err == nil {
+		err = serr
+	}
+	return err
+}
+
+// pollFeed fetches, filters, decodes and emits a feed, updating fs with the
+// response validators. It returns the number of articles emitted.
+func (w *Watcher) pollFeed(ctx context.Context, feed Feed, fs *FeedState, emit func(Article) bool) (int, error) {
+	items, err := w.fetch(ctx, feed, fs)
+	fs.LastError = ""
+	if err != nil || len(items) == 0 {
+		return 0, err
+	}
+
+	// Claim the new items; the seen ones still listed are kept for another TTL
+	var fresh []gnews.FeedItem
+	var ids, listed []string
+	w.mu.Lock()
+	for _, item := range items {
+		id := itemID(item)
+		if id == "" || w.pending[id] {
+			continue
+		}
+		if w.store.Seen(id) {
+			listed = append(listed, id)
+			continue
+		}
+		w.pending[id] = true
+		fresh = append(fresh, item)
+		ids = append(ids, id)
+	}
+	w.mu.Unlock()
+	defer func() {
+		w.mu.Lock()
+		for _, id := range ids {
+			delete(w.pending, id)
+		}
+		w.mu.Unlock()
+	}()
+
+	links := make([]string, len(fresh))
+	for i, item := range fresh {
+		links[i] = item.Link
+	}
+	results := gnews.NewConcurrentDecoder(w.decoder, 0).DecodeURLsWithContext(ctx, links, nil)
+
+	seen := listed
+	emitted, failed := 0, 0
+	stopped := false
+	for i, result := range results {
+		if !result.Status {
+			failed++
+			w.onError(feed, fmt.Errorf("%s: %s", fresh[i].Link, result.Message))
+			continue
+		}
+		if stopped {
+			continue
+		}
+		article := Article{
+			Feed:      feed.URL,
+			FeedTitle: feed.Title,
+			Tags:      feed.Tags,
+			ID:        ids[i],
+			Title:     fresh[i].Title,
+			Link:      fresh[i].Link,
+			URL:       result.DecodedURL,
+			Source:    fresh[i].Source,
+			Published: fresh[i].Published,
+		}
+		if !emit(article) {
+			stopped = true
+			continue
+		}
+		emitted++
+		seen = append(seen, ids[i])
+	}
+	if failed > 0 {
+		fs.LastError = fmt.Sprintf("%d of %d new items could not be decoded", failed, len(fresh))
+	}
+	if failed > 0 || stopped {
+		// Fetch the feed in full next time so the failed and unsent items are retried
+		fs.Validators = Validators{}
+	}
+	return emitted, w.store.MarkSeen(seen...)
+}
+
+// fetch requests a feed with the validators in fs and parses it.
+// It returns no items when the feed is unchanged.
+func (w *Watcher) fetch(ctx context.Context, feed Feed, fs *FeedState) ([]gnews.FeedItem, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feed.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	if fs.ETag != "" {
+		req.Header.Set("If-None-Match", fs.ETag)
+	}
+	if fs.LastModified != "" {
+		req.Header.Set("If-Modified-Since", fs.LastModified)
+	}
+
+	resp, err := w.decoder.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("feed request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil, nil
+	case http.StatusOK:
+	default:
+		return nil, fmt.Errorf("feed request failed with status: %d", resp.StatusCode)
+	}
+
+	parsed, err := gnews.ParseFeed(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only keep the validators once the body was read successfully
+	fs.Validators = Validators{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+	return parsed.Items, nil
+}
+
+// PollAll polls every feed once, a few at a time, and passes new articles to
+// emit. Calls to emit are serialized. Feed errors go to the error handler;
+// PollAll only fails when emit does or the context is cancelled.
+func (w *Watcher) PollAll(ctx context.Context, feeds []Feed, emit func(Article) error) error {
+	e := &emitter{emit: emit}
+	sem := make(chan struct{}, 4)
+	var wg sync.WaitGroup
+	for _, feed := range feeds {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return ctx.Err()
+		}
+		wg.Add(1)
+		go func(feed Feed) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			w.pollAndEmit(ctx, feed, e)
+		}(feed)
+	}
+	wg.Wait()
+
+	if e.err != nil {
+		return e.err
+	}
+	return ctx.Err()
+}
+
+// Run polls every feed on its own jittered schedule until the context is
+// cancelled or emit fails. First polls are spread over one interval so a
+// large feed list does not hit Google all at once.
+func (w *Watcher) Run(ctx context.Context, feeds []Feed, emit func(Article) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	e := &emitter{emit: emit, cancel: cancel}
+	var wg sync.WaitGroup
+	for _, feed := range feeds {
+		wg.Add(1)
+		go func(feed Feed) {
+			defer wg.Done()
+			timer := time.NewTimer(time.Duration(rand.Int63n(int64(w.interval))))
+			defer timer.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-timer.C:
+				}
+				w.pollAndEmit(ctx, feed, e)
+				timer.Reset(w.nextWait())
+			}
+		}(feed)
+	}
+	wg.Wait()
+
+	if e.err != nil {
+		return e.err
+	}
+	return ctx.Err()
+}
+
+func (w *Watcher) pollAndEmit(ctx context.Context, feed Feed, e *emitter) {
+	if err := w.poll(ctx, feed, e.send); err != nil && ctx.Err() == nil {
+		w.onError(feed, err)
+	}
+}
+
+// nextWait returns the interval spread by up to the jitter fraction either way
+func (w *Watcher) nextWait() time.Duration {
+	spread := (rand.Float64()*2 - 1) * w.jitter
+	return time.Duration(float64(w.interval) * (1 + spread))
+}
+
+// emitter serializes calls to emit and records its first error
+type emitter struct {
+	mu     sync.Mutex
+	emit   func(Article) error
+	err    error
+	cancel context.CancelFunc
+}
+
+func (e *emitter) send(article Article) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.err != nil {
+		return false
+	}
+	if err := e.emit(article); err != nil {
+		e.err = err
+		if e.cancel != nil {
+			e.cancel()
+		}
+		return false
+	}
+	return true
+}
+
+// itemID identifies an item by its Google News article ID, else its guid or link
+func itemID(item gnews.FeedItem) string {
+	if c, err := gnews.ClassifyURL(item.Link); err == nil && c.ID != "" {
+		return c.ID
+	}
+	if item.GUID != "" {
+		return item.GUID
+	}
+	return item.Link
+}
+
+// ReadFeedList reads a list of feed URLs, one per line. Blank lines and lines
+// starting with # are ignored.
+func ReadFeedList(r io.Reader) ([]Feed, error) {
+	var feeds []Feed
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if !strings.HasPrefix(text, "http://") && !strings.HasPrefix(text, "https://") {
+			return nil, fmt.Errorf("line %d: %q is not an http(s) URL", line, text)
+		}
+		feeds = append(feeds, Feed{URL: text})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(feeds) == 0 {
+		return nil, errors.New("no feeds in list")
+	}
+	return feeds, nil
+}
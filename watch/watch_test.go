@@ -0,0 +1,221 @@
+package watch_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gnews "github.com/alainmucyo/google-news-url-decoder"
+	"github.com/alainmucyo/google-news-url-decoder/gnewstest"
+	"github.com/alainmucyo/google-news-url-decoder/watch"
+)
+
+// rssFeed returns a Google News RSS feed with one item per article ID
+func rssFeed(ids ...string) string {
+	var items strings.Builder
+	for _, id := range ids {
+		fmt.Fprintf(&items, `<item><title>%[1]s</title><link>https://news.google.com/rss/articles/%[1]s?oc=5</link>`+
+			`<guid isPermaLink="false">%[1]s</guid><source url="https://example.com">Example</source></item>`, id)
+	}
+	return `<rss version="2.0"><channel><title>Feed</title>` + items.String() + `</channel></rss>`
+}
+
+func TestWatcher_PollAll(t *testing.T) {
+	srv := gnewstest.NewServer()
+	defer srv.Close()
+
+	ids := make([]string, 3)
+	for i := range ids {
+		ids[i], _ = gnews.EncodeArticleID(fmt.Sprintf("AU_yqLwatch%d", i), gnews.EncodeOptions{})
+		srv.AddArticle(ids[i], fmt.Sprintf("https://example.com/%d", i))
+	}
+	srv.AddFeed("/rss/search", rssFeed(ids[0], ids[1]))
+	srv.AddFeed("/rss/topics/world", rssFeed(ids[1]))
+	srv.AddFeed("/rss/topics/broken", rssFeed("CBMiunknown", ids[0]))
+
+	decoder, err := gnews.NewGoogleDecoder(srv.Option())
+	if err != nil {
+		t.Fatalf("Failed to create GoogleDecoder: %v", err)
+	}
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	store, err := watch.OpenFileStore(statePath)
+	if err != nil {
+		t.Fatalf("OpenFileStore() error = %v", err)
+	}
+
+	var mu sync.Mutex
+	var failures []string
+	w := watch.New(decoder, store, watch.WithErrorHandler(func(feed watch.Feed, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		failures = append(failures, err.Error())
+	}))
+	feeds := []watch.Feed{{URL: srv.URL + "/rss/search?q=go"}, {URL: srv.URL + "/rss/topics/world"}}
+
+	poll := func() map[string]string {
+		t.Helper()
+		got := make(map[string]string)
+		err := w.PollAll(context.Background(), feeds, func(a watch.Article) error {
+			if _, dup := got[a.ID]; dup {
+				t.Errorf("article %s emitted twice", a.ID)
+			}
+			got[a.ID] = a.URL
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("PollAll() error = %v", err)
+		}
+		return got
+	}
+
+	// First poll emits every decodable item once, across feeds
+	got := poll()
+	if len(got) != 2 || got[ids[0]] != "https://example.com/0" || got[ids[1]] != "https://example.com/1" {
+		t.Errorf("first poll = %v", got)
+	}
+
+	// Unchanged feeds answer 304 and cost no decode
+	before := srv.Requests()
+	if got := poll(); len(got) != 0 {
+		t.Errorf("second poll = %v, want nothing", got)
+	}
+	if n := srv.Requests() - before; n != len(feeds) {
+		t.Errorf("second poll made %d requests, want %d", n, len(feeds))
+	}
+
+	// Only the new item of a changed feed is decoded
+	srv.AddFeed("/rss/search", rssFeed(ids[2], ids[0], ids[1]))
+	if got := poll(); len(got) != 1 || got[ids[2]] != "https://example.com/2" {
+		t.Errorf("third poll = %v", got)
+	}
+
+	// Items that fail to decode are reported and retried on every poll
+	broken := watch.Feed{URL: srv.URL + "/rss/topics/broken", Title: "Broken", Tags: []string{"Tech"}}
+	for i := 1; i <= 2; i++ {
+		if articles, err := w.Poll(context.Background(), broken); err != nil || len(articles) != 0 {
+			t.Errorf("Poll(broken) = %v, %v, want nothing", articles, err)
+		}
+		if len(failures) != i || !strings.Contains(failures[i-1], "CBMiunknown") {
+			t.Errorf("failures = %v", failures)
+		}
+	}
+	if fs := store.Feed(broken.URL); fs.LastError == "" || fs.ETag != "" || fs.LastPolled.IsZero() {
+		t.Errorf("broken feed state = %+v", fs)
+	}
+
+	// The state survives a restart
+	reopened, err := watch.OpenFileStore(statePath)
+	if err != nil {
+		t.Fatalf("OpenFileStore() error = %v", err)
+	}
+	for _, id := range ids {
+		if !reopened.Seen(id) {
+			t.Errorf("reopened store has not seen %s", id)
+		}
+	}
+	if reopened.Seen("CBMiunknown") {
+		t.Error("failed item was marked as seen")
+	}
+	search, world := reopened.Feed(feeds[0].URL), reopened.Feed(feeds[1].URL)
+	if search.ETag == "" || search.LastError != "" || search.Articles+world.Articles != 3 {
+		t.Errorf("reopened feed states = %+v, %+v", search, world)
+	}
+}
+
+func TestWatcher_EmitFailureKeepsArticlesUnseen(t *testing.T) {
+	srv := gnewstest.NewServer()
+	defer srv.Close()
+
+	ids := make([]string, 2)
+	for i := range ids {
+		ids[i], _ = gnews.EncodeArticleID(fmt.Sprintf("AU_yqLemit%d", i), gnews.EncodeOptions{})
+		srv.AddArticle(ids[i], fmt.Sprintf("https://example.com/%d", i))
+	}
+	srv.AddFeed("/rss/search", rssFeed(ids...))
+
+	decoder, err := gnews.NewGoogleDecoder(srv.Option())
+	if err != nil {
+		t.Fatalf("Failed to create GoogleDecoder: %v", err)
+	}
+	store := watch.NewMemoryStore()
+	w := watch.New(decoder, store)
+	feeds := []watch.Feed{{URL: srv.URL + "/rss/search?q=go"}}
+
+	// The output fails after the first article
+	writeErr := errors.New("disk full")
+	var got []string
+	err = w.PollAll(context.Background(), feeds, func(a watch.Article) error {
+		if len(got) == 1 {
+			return writeErr
+		}
+		got = append(got, a.ID)
+		return nil
+	})
+	if !errors.Is(err, writeErr) || len(got) != 1 {
+		t.Fatalf("PollAll() = %v, %v, want the emit error after one article", got, err)
+	}
+	if store.Seen(ids[1]) {
+		t.Error("article that failed to emit was marked as seen")
+	}
+
+	// The next poll fetches the feed in full and emits the other article
+	got = nil
+	err = w.PollAll(context.Background(), feeds, func(a watch.Article) error {
+		got = append(got, a.ID)
+		return nil
+	})
+	if err != nil || len(got) != 1 || got[0] != ids[1] {
+		t.Errorf("PollAll() = %v, %v, want %s", got, err, ids[1])
+	}
+}
+
+func TestFileStore_SaveAndPrune(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	old := time.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339)
+	if err := os.WriteFile(statePath, []byte(`{"seen":{"gone":"`+old+`","listed":"`+old+`"},"feeds":{}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := watch.OpenFileStore(statePath, watch.WithSeenTTL(24*time.Hour))
+	if err != nil {
+		t.Fatalf("OpenFileStore() error = %v", err)
+	}
+	store.MarkSeen("listed", "new")
+
+	// Nothing is written before Save
+	if reopened, _ := watch.OpenFileStore(statePath); reopened.Seen("new") {
+		t.Error("MarkSeen() wrote the file before Save()")
+	}
+	if err := store.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	reopened, err := watch.OpenFileStore(statePath)
+	if err != nil {
+		t.Fatalf("OpenFileStore() error = %v", err)
+	}
+	for id, want := range map[string]bool{"gone": false, "listed": true, "new": true} {
+		if reopened.Seen(id) != want {
+			t.Errorf("Seen(%q) = %v, want %v", id, !want, want)
+		}
+	}
+}
+
+func TestReadFeedList(t *testing.T) {
+	feeds, err := watch.ReadFeedList(strings.NewReader("# Google News\n\nhttps://news.google.com/rss/search?q=go\n  https://news.google.com/rss  \n"))
+	if err != nil || len(feeds) != 2 || feeds[1].URL != "https://news.google.com/rss" {
+		t.Errorf("ReadFeedList() = %v, %v", feeds, err)
+	}
+
+	for _, input := range []string{"", "# only comments\n", "news.google.com/rss\n"} {
+		if _, err := watch.ReadFeedList(strings.NewReader(input)); err == nil {
+			t.Errorf("ReadFeedList(%q) succeeded, want error", input)
+		}
+	}
+}